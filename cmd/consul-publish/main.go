@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/AlekSi/pointer"
 	"github.com/coreos/go-systemd/v22/daemon"
@@ -17,10 +18,13 @@ import (
 
 	"github.com/jfk9w/consul-publish/internal/consul"
 	"github.com/jfk9w/consul-publish/internal/listeners/caddy"
+	"github.com/jfk9w/consul-publish/internal/listeners/haproxy"
 	"github.com/jfk9w/consul-publish/internal/listeners/homepage"
 	"github.com/jfk9w/consul-publish/internal/listeners/hosts"
 	"github.com/jfk9w/consul-publish/internal/listeners/metrics"
 	"github.com/jfk9w/consul-publish/internal/listeners/mikrotik"
+	"github.com/jfk9w/consul-publish/internal/listeners/nginx"
+	mtkapi "github.com/jfk9w/consul-publish/internal/mikrotik"
 )
 
 type Config struct {
@@ -42,14 +46,24 @@ type Config struct {
 		caddy.Config `yaml:",inline"`
 	} `yaml:"caddy,omitempty" doc:"Caddy target settings"`
 
+	Nginx struct {
+		Enabled      bool `yaml:"enabled,omitempty" doc:"Enable nginx target"`
+		nginx.Config `yaml:",inline"`
+	} `yaml:"nginx,omitempty" doc:"nginx target settings"`
+
+	HAProxy struct {
+		Enabled        bool `yaml:"enabled,omitempty" doc:"Enable HAProxy target"`
+		haproxy.Config `yaml:",inline"`
+	} `yaml:"haproxy,omitempty" doc:"HAProxy target settings"`
+
 	Homepage struct {
 		Enabled         bool `yaml:"enabled,omitempty" doc:"Enable Homepage target"`
 		homepage.Config `yaml:",inline"`
 	} `yaml:"homepage,omitempty" doc:"Homepage target settings"`
 
 	Mikrotik struct {
-		Enabled                 bool `yaml:"enabled,omitempty" doc:"Enable MikroTik DNS target"`
-		mikrotik.ListenerConfig `yaml:",inline"`
+		Enabled         bool `yaml:"enabled,omitempty" doc:"Enable MikroTik DNS target"`
+		mikrotik.Config `yaml:",inline"`
 	} `yaml:"mikrotik,omitempty" doc:"MikroTik DNS target settings"`
 
 	Metrics struct {
@@ -133,17 +147,80 @@ func main() {
 		listeners = append(listeners, caddy.New(cfg.Caddy.Config))
 	}
 
+	if cfg.Nginx.Enabled {
+		listeners = append(listeners, nginx.New(cfg.Nginx.Config))
+	}
+
+	if cfg.HAProxy.Enabled {
+		listeners = append(listeners, haproxy.New(cfg.HAProxy.Config))
+	}
+
 	if cfg.Homepage.Enabled {
-		listeners = append(listeners, homepage.New(cfg.Homepage.Config))
+		listener, err := homepage.New(cfg.Homepage.Config)
+		if err != nil {
+			panic(err)
+		}
+
+		listeners = append(listeners, listener)
 	}
 
+	var (
+		elect     func(ctx context.Context) error
+		importers []*mikrotik.LeaseImporter
+	)
+
 	if cfg.Mikrotik.Enabled {
-		listeners = append(listeners, mikrotik.NewListener(cfg.Mikrotik.ListenerConfig))
+		listener, err := mikrotik.NewMultiListener(cfg.Mikrotik.Config)
+		if err != nil {
+			panic(err)
+		}
+
+		configs, err := cfg.Mikrotik.Listeners()
+		if err != nil {
+			panic(err)
+		}
+
+		for _, cfg := range configs {
+			if !cfg.Leases.Enabled {
+				continue
+			}
+
+			importer, err := mikrotik.NewLeaseImporter(cfg, client.Catalog(), client.KV())
+			if err != nil {
+				panic(err)
+			}
+
+			importers = append(importers, importer)
+		}
+
+		listeners = append(listeners, listener)
+		if cfg.Mikrotik.Cluster.Enabled {
+			lock, err := client.LockOpts(&capi.LockOptions{
+				Key:         cfg.Mikrotik.Cluster.Lock,
+				SessionName: "consul-publish mikrotik leader",
+			})
+			if err != nil {
+				panic(err)
+			}
+
+			elect = func(ctx context.Context) error {
+				return consul.Elect(ctx, lock, time.Duration(cfg.Mikrotik.Cluster.Retry), func(ctx context.Context, leader bool) {
+					listener.SetLeader(ctx, leader)
+					for _, importer := range importers {
+						importer.SetLeader(ctx, leader)
+					}
+				})
+			}
+		}
 	}
 
 	var metricsListener *metrics.Listener
 	if cfg.Metrics.Enabled {
 		metricsListener = metrics.New(cfg.Metrics.Config)
+		if cfg.Mikrotik.Enabled {
+			metricsListener.MustRegister(mtkapi.Collectors()...)
+		}
+
 		listeners = append(listeners, metricsListener)
 	}
 
@@ -155,6 +232,14 @@ func main() {
 		eg.Go(func() error { return metricsListener.ListenAndServe(ctx) })
 	}
 
+	if elect != nil {
+		eg.Go(func() error { return elect(ctx) })
+	}
+
+	for _, importer := range importers {
+		eg.Go(func() error { return importer.Run(ctx) })
+	}
+
 	if err := eg.Wait(); err != nil {
 		panic(err)
 	}
@@ -166,6 +251,10 @@ type systemdListener struct {
 	once sync.Once
 }
 
+func (l *systemdListener) Name() string {
+	return "systemd"
+}
+
 func (l *systemdListener) KV() []string {
 	return nil
 }
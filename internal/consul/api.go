@@ -10,3 +10,16 @@ type Listener interface {
 	KV() []string
 	Notify(ctx context.Context, state *State) error
 }
+
+// Named is implemented by listeners that are identified in logs and metrics by a name
+// rather than by their type. The name matches the listener label of their other metrics.
+type Named interface {
+	Name() string
+}
+
+// MultiTarget is implemented by listeners that notify several targets, such as routers or
+// dashboards, and record the outcome of every target with ObserveNotify under its own name.
+// The watcher does not record the notifications of these listeners itself.
+type MultiTarget interface {
+	MultiTarget()
+}
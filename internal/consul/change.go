@@ -20,6 +20,7 @@ func (c nodeChange) change(state *State) {
 		entry.ID = node.ID
 		entry.Name = node.Node
 		entry.Address = node.Address
+		entry.TaggedAddresses = node.TaggedAddresses
 		entry.Meta = node.Meta
 		entry.Groups = lib.SetOf(strings.Fields(node.Meta[NodeGroupsKey])...)
 		state.Nodes[node.Node] = entry
@@ -50,24 +51,34 @@ func (c *serviceChange) change(state *State) {
 			address = c.Node.Address
 		}
 
+		var tagged map[string]string
+		if len(service.TaggedAddresses) > 0 {
+			tagged = make(map[string]string, len(service.TaggedAddresses))
+			for tag, address := range service.TaggedAddresses {
+				tagged[tag] = address.Address
+			}
+		}
+
 		services[i] = Service{
-			ID:      service.ID,
-			Name:    service.Service,
-			Address: address,
-			Port:    service.Port,
-			Tags:    tags,
-			Meta:    service.Meta,
+			ID:              service.ID,
+			Name:            service.Service,
+			Address:         address,
+			TaggedAddresses: tagged,
+			Port:            service.Port,
+			Tags:            tags,
+			Meta:            service.Meta,
 		}
 	}
 
 	delete(state.Nodes, c.Node.Node)
 	state.Nodes[c.Node.Node] = Node{
-		ID:       c.Node.ID,
-		Name:     c.Node.Node,
-		Address:  c.Node.Address,
-		Groups:   lib.SetOf(strings.Fields(c.Node.Meta[NodeGroupsKey])...),
-		Meta:     c.Node.Meta,
-		Services: services,
+		ID:              c.Node.ID,
+		Name:            c.Node.Node,
+		Address:         c.Node.Address,
+		TaggedAddresses: c.Node.TaggedAddresses,
+		Groups:          lib.SetOf(strings.Fields(c.Node.Meta[NodeGroupsKey])...),
+		Meta:            c.Node.Meta,
+		Services:        services,
 	}
 
 	state.groups = nil
@@ -66,23 +66,26 @@ func (s *State) Group(name string) lib.Set[string] {
 }
 
 // Service represents a single Consul service registration on a node.
+// TaggedAddresses maps address tags such as "lan_ipv4" or "wan" to addresses.
 type Service struct {
-	ID      string
-	Name    string
-	Address string
-	Port    int
-	Tags    map[string]bool
-	Meta    map[string]string
+	ID              string
+	Name            string
+	Address         string
+	TaggedAddresses map[string]string
+	Port            int
+	Tags            map[string]bool
+	Meta            map[string]string
 }
 
 // Node represents a Consul catalog node together with all its service registrations.
 type Node struct {
-	ID       string
-	Name     string
-	Address  string
-	Groups   lib.Set[string]
-	Meta     map[string]string
-	Services []Service
+	ID              string
+	Name            string
+	Address         string
+	TaggedAddresses map[string]string
+	Groups          lib.Set[string]
+	Meta            map[string]string
+	Services        []Service
 }
 
 // KV is the sealed interface for entries in the KV tree (either a Folder or a Value).
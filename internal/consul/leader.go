@@ -0,0 +1,54 @@
+package consul
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Locker is a distributed lock used for leader election. *capi.Lock satisfies this interface.
+type Locker interface {
+	Lock(stopCh <-chan struct{}) (<-chan struct{}, error)
+	Unlock() error
+}
+
+// Elect campaigns for leadership with locker until ctx is cancelled. onChange is called with true
+// after the lock is acquired and with false after it is lost or released on shutdown.
+// Failed acquisition attempts are retried after the retry interval.
+func Elect(ctx context.Context, locker Locker, retry time.Duration, onChange func(ctx context.Context, leader bool)) error {
+	for {
+		lost, err := locker.Lock(ctx.Done())
+		if err != nil {
+			slog.Warn("failed to acquire leader lock", "error", err)
+			select {
+			case <-time.After(retry):
+				continue
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		if lost == nil {
+			return nil
+		}
+
+		slog.Info("acquired leadership")
+		onChange(ctx, true)
+
+		select {
+		case <-lost:
+			slog.Warn("lost leadership")
+			onChange(ctx, false)
+			// The lock has to be reset before it can be acquired again.
+			_ = locker.Unlock()
+
+		case <-ctx.Done():
+			onChange(ctx, false)
+			if err := locker.Unlock(); err != nil {
+				slog.Warn("failed to release leader lock", "error", err)
+			}
+
+			return nil
+		}
+	}
+}
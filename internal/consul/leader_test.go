@@ -0,0 +1,77 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testLocker struct {
+	mu       sync.Mutex
+	attempts int
+	lost     chan struct{}
+	unlocked int
+}
+
+func (l *testLocker) Lock(stopCh <-chan struct{}) (<-chan struct{}, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.attempts++
+	if l.attempts == 1 {
+		return nil, errors.New("session error")
+	}
+
+	if l.attempts > 3 {
+		<-stopCh
+		return nil, nil
+	}
+
+	l.lost = make(chan struct{})
+	return l.lost, nil
+}
+
+func (l *testLocker) Unlock() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.unlocked++
+	return nil
+}
+
+func TestElect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	locker := new(testLocker)
+	changes := make(chan bool)
+	done := make(chan error)
+	go func() {
+		done <- Elect(ctx, locker, time.Millisecond, func(_ context.Context, leader bool) { changes <- leader })
+	}()
+
+	var got []bool
+	got = append(got, <-changes)
+
+	// Losing the lock resets it and campaigns again.
+	locker.mu.Lock()
+	close(locker.lost)
+	locker.mu.Unlock()
+	got = append(got, <-changes, <-changes)
+
+	cancel()
+	got = append(got, <-changes)
+	if err := <-done; err != nil {
+		t.Fatalf("Elect() error = %v", err)
+	}
+
+	if want := []bool{true, false, true, false}; !slices.Equal(got, want) {
+		t.Errorf("leadership changes = %v, want %v", got, want)
+	}
+	if locker.unlocked != 2 {
+		t.Errorf("Unlock() calls = %d, want 2", locker.unlocked)
+	}
+}
@@ -0,0 +1,118 @@
+package consul
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	capi "github.com/hashicorp/consul/api"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	notifyDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "consul_publish_listener_notify_duration_seconds",
+		Help:    "Duration of listener notifications.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"listener"})
+
+	notifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "consul_publish_listener_notifications_total",
+		Help: "Listener notifications by result, success or failure.",
+	}, []string{"listener", "result"})
+
+	lastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "consul_publish_listener_last_success_timestamp_seconds",
+		Help: "Unix timestamp of the last successful listener notification.",
+	}, []string{"listener"})
+
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "consul_publish_watch_query_duration_seconds",
+		Help:    "Duration of Consul blocking queries by watch type, nodes, services or kv.",
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 9),
+	}, []string{"watch"})
+
+	queryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "consul_publish_watch_query_errors_total",
+		Help: "Failed Consul blocking queries by watch type.",
+	}, []string{"watch"})
+
+	lastIndex = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "consul_publish_watch_last_index",
+		Help: "Last Consul index returned by the nodes or kv blocking queries, by kv prefix.",
+	}, []string{"watch", "prefix"})
+
+	serviceWatchers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "consul_publish_watch_service_watchers",
+		Help: "Number of active per-node service watchers.",
+	})
+
+	// changes is the change channel of the running watcher.
+	changes atomic.Pointer[chan change]
+
+	changeQueue = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "consul_publish_watch_change_queue_length",
+		Help: "Number of changes waiting to be applied to the state.",
+	}, func() float64 {
+		if ch := changes.Load(); ch != nil {
+			return float64(len(*ch))
+		}
+
+		return 0
+	})
+
+	skippedNotifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "consul_publish_watch_skipped_notifications_total",
+		Help: "Changes that did not notify listeners by reason; unchanged when the state stayed the same, debounced when a newer change arrived within the debounce window.",
+	}, []string{"reason"})
+
+	stateDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "consul_publish_watch_state_operation_duration_seconds",
+		Help:    "Duration of state operations by type, deepcopy or deepequal.",
+		Buckets: prometheus.ExponentialBuckets(0.0001, 4, 8),
+	}, []string{"operation"})
+)
+
+// Collectors returns the Prometheus collectors maintained by the watcher.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		notifyDuration, notifications, lastSuccess,
+		queryDuration, queryErrors, lastIndex, serviceWatchers, changeQueue, skippedNotifications, stateDuration,
+	}
+}
+
+// ObserveNotify records the outcome of a notification of the named listener that started at start.
+func ObserveNotify(listener string, start time.Time, err error) {
+	notifyDuration.WithLabelValues(listener).Observe(time.Since(start).Seconds())
+	if err != nil {
+		notifications.WithLabelValues(listener, "failure").Inc()
+		return
+	}
+
+	notifications.WithLabelValues(listener, "success").Inc()
+	lastSuccess.WithLabelValues(listener).SetToCurrentTime()
+}
+
+// observeQuery records a blocking query of the watch type that started at start.
+// Errors caused by ctx cancellation are not counted. The index is not recorded for the
+// per-node services queries, as the indexes of different nodes are unrelated. The index of
+// a kv query is recorded under its prefix, which is empty for the nodes query.
+func observeQuery(ctx context.Context, watch, prefix string, start time.Time, meta *capi.QueryMeta, err error) {
+	if err != nil {
+		if ctx.Err() == nil {
+			queryErrors.WithLabelValues(watch).Inc()
+		}
+
+		return
+	}
+
+	queryDuration.WithLabelValues(watch).Observe(time.Since(start).Seconds())
+	if watch != "services" {
+		lastIndex.WithLabelValues(watch, prefix).Set(float64(meta.LastIndex))
+	}
+}
+
+// timeState records the duration of a state operation that started at start.
+func timeState(operation string, start time.Time) {
+	stateDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,84 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	capi "github.com/hashicorp/consul/api"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveNotify(t *testing.T) {
+	success, failure := notifications.WithLabelValues("notify-test", "success"), notifications.WithLabelValues("notify-test", "failure")
+	successBefore, failureBefore := testutil.ToFloat64(success), testutil.ToFloat64(failure)
+
+	ObserveNotify("notify-test", time.Now(), nil)
+	ObserveNotify("notify-test", time.Now(), errors.New("boom"))
+	ObserveNotify("notify-test", time.Now(), errors.New("boom"))
+
+	if got := testutil.ToFloat64(success) - successBefore; got != 1 {
+		t.Fatalf("successful notifications = %v, want 1", got)
+	}
+
+	if got := testutil.ToFloat64(failure) - failureBefore; got != 2 {
+		t.Fatalf("failed notifications = %v, want 2", got)
+	}
+
+	if got := testutil.ToFloat64(lastSuccess.WithLabelValues("notify-test")); got < float64(time.Now().Add(-time.Minute).Unix()) {
+		t.Fatalf("last success timestamp = %v, want about now", got)
+	}
+}
+
+func TestObserveQuery(t *testing.T) {
+	queryErrorsBefore := testutil.ToFloat64(queryErrors.WithLabelValues("query-test"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	observeQuery(ctx, "query-test", "one/", time.Now(), &capi.QueryMeta{LastIndex: 42}, nil)
+	observeQuery(ctx, "query-test", "two/", time.Now(), &capi.QueryMeta{LastIndex: 7}, nil)
+	observeQuery(ctx, "query-test", "one/", time.Now(), nil, errors.New("boom"))
+	cancel()
+	observeQuery(ctx, "query-test", "one/", time.Now(), nil, context.Canceled)
+
+	// Every kv prefix keeps its own index.
+	if got := testutil.ToFloat64(lastIndex.WithLabelValues("query-test", "one/")); got != 42 {
+		t.Fatalf("last index of one/ = %v, want 42", got)
+	}
+
+	if got := testutil.ToFloat64(lastIndex.WithLabelValues("query-test", "two/")); got != 7 {
+		t.Fatalf("last index of two/ = %v, want 7", got)
+	}
+
+	if got := testutil.ToFloat64(queryErrors.WithLabelValues("query-test")) - queryErrorsBefore; got != 1 {
+		t.Fatalf("query errors = %v, want 1", got)
+	}
+
+	if got := testutil.CollectAndCount(queryDuration, "consul_publish_watch_query_duration_seconds"); got == 0 {
+		t.Fatal("query duration was not observed")
+	}
+}
+
+func TestObserveQuerySkipsServicesIndex(t *testing.T) {
+	observeQuery(context.Background(), "services", "", time.Now(), &capi.QueryMeta{LastIndex: 7}, nil)
+	if lastIndex.DeleteLabelValues("services", "") {
+		t.Fatal("last index recorded for the services watch")
+	}
+}
+
+type (
+	typedListener struct{ Listener }
+	namedListener struct{ Listener }
+)
+
+func (namedListener) Name() string { return "named" }
+
+func TestListenerName(t *testing.T) {
+	if got := listenerName(namedListener{}); got != "named" {
+		t.Fatalf("name = %q, want %q", got, "named")
+	}
+
+	if got := listenerName(typedListener{}); got != "consul.typedListener" {
+		t.Fatalf("name = %q, want the type name", got)
+	}
+}
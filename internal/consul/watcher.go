@@ -44,6 +44,8 @@ func Watch(ctx context.Context, client *capi.Client, listeners ...Listener) erro
 		work:  eg,
 	}
 
+	changes.Store(&w.change)
+
 	keys := make(map[string]bool)
 	for _, listener := range listeners {
 		for _, prefix := range listener.KV() {
@@ -91,15 +93,22 @@ func Watch(ctx context.Context, client *capi.Client, listeners ...Listener) erro
 			ts = time.Now()
 			mu.Unlock()
 
-			if reflect.DeepEqual(w.state, prev) {
+			start := time.Now()
+			equal := reflect.DeepEqual(w.state, prev)
+			timeState("deepequal", start)
+			if equal {
+				skippedNotifications.WithLabelValues("unchanged").Inc()
 				continue
 			}
 
+			start = time.Now()
 			prev = new(State)
 			if err := deepcopy.Copy(prev, w.state); err != nil {
 				return err
 			}
 
+			timeState("deepcopy", start)
+
 			tts := ts
 			w.work.Go(cancellable(ctx, func() error {
 				<-time.After(5 * time.Second)
@@ -107,22 +116,30 @@ func Watch(ctx context.Context, client *capi.Client, listeners ...Listener) erro
 				defer mu.Unlock()
 				if tts != ts {
 					slog.Debug("skipping notification")
+					skippedNotifications.WithLabelValues("debounced").Inc()
 					return nil
 				}
 
 				slog.Info("notifying listeners")
 				for _, listener := range listeners {
-					name := reflect.TypeOf(listener).String()
+					name := listenerName(listener)
 					log := slog.With("listener", name)
 					log.Debug("notifying listener")
 
+					start := time.Now()
 					var state State
 					if err := deepcopy.Copy(&state, w.state); err != nil {
 						log.Error("failed to copy state for listener", "error", err)
 						return err
 					}
 
-					if err := listener.Notify(ctx, &state); err != nil {
+					timeState("deepcopy", start)
+					start = time.Now()
+					err := listener.Notify(ctx, &state)
+					if _, ok := listener.(MultiTarget); !ok {
+						ObserveNotify(name, start, err)
+					}
+					if err != nil {
 						log.Error("listener notification failed", "error", err)
 						return err
 					}
@@ -138,6 +155,15 @@ func Watch(ctx context.Context, client *capi.Client, listeners ...Listener) erro
 	return w.work.Wait()
 }
 
+// listenerName returns the name of listener used in logs and metrics.
+func listenerName(listener Listener) string {
+	if named, ok := listener.(Named); ok {
+		return named.Name()
+	}
+
+	return reflect.TypeOf(listener).String()
+}
+
 func (w *watcher) watchNodes(ctx context.Context, client *capi.Client) {
 	var (
 		init = new(sync.WaitGroup)
@@ -158,7 +184,7 @@ func (w *watcher) watchNodes(ctx context.Context, client *capi.Client) {
 			once.Do(w.init.Done)
 		}()
 
-		for nodes, err := range watch(ctx, client, nodes()) {
+		for nodes, err := range watch(ctx, client, "nodes", "", nodes()) {
 			if err != nil {
 				return err
 			}
@@ -218,7 +244,9 @@ func (w *watcher) watchServices(ctx context.Context, client *capi.Client, node s
 	w.work.Go(cancellable(ctx, func() (err error) {
 		log := slog.With("node", node)
 		log.Info("watcher started")
+		serviceWatchers.Inc()
 		defer func() {
+			serviceWatchers.Dec()
 			if isCanceled(ctx, err) {
 				log.Info("watcher stopped")
 			} else {
@@ -231,7 +259,7 @@ func (w *watcher) watchServices(ctx context.Context, client *capi.Client, node s
 			}
 		}()
 
-		for services, err := range watch(ctx, client, services(node)) {
+		for services, err := range watch(ctx, client, "services", "", services(node)) {
 			if err != nil {
 				return err
 			}
@@ -270,7 +298,7 @@ func (w *watcher) watchKeys(ctx context.Context, client *capi.Client, prefix str
 			once.Do(w.init.Done)
 		}()
 
-		for keys, err := range watch(ctx, client, keys(prefix)) {
+		for keys, err := range watch(ctx, client, "kv", prefix, keys(prefix)) {
 			if err != nil {
 				return err
 			}
@@ -314,7 +342,9 @@ type WatchFunc[V any] func(client *capi.Client, options *capi.QueryOptions) (V,
 
 // watch returns an iterator that repeatedly issues a blocking query via fn and yields each
 // new value as it arrives. It stops when ctx is cancelled or fn returns an error.
-func watch[V any](ctx context.Context, client *capi.Client, fn WatchFunc[V]) iter.Seq2[V, error] {
+// Queries are reported to the metrics under the watch type label, and the last index of
+// a kv query also under its prefix.
+func watch[V any](ctx context.Context, client *capi.Client, watchType, prefix string, fn WatchFunc[V]) iter.Seq2[V, error] {
 	return func(yield func(V, error) bool) {
 		var none V
 		index := uint64(0)
@@ -323,7 +353,9 @@ func watch[V any](ctx context.Context, client *capi.Client, fn WatchFunc[V]) ite
 			options.WaitIndex = index
 			options.Datacenter = "dc1"
 
+			start := time.Now()
 			value, meta, err := fn(client, options.WithContext(ctx))
+			observeQuery(ctx, watchType, prefix, start, meta, err)
 			if err != nil {
 				yield(none, err)
 				return
@@ -6,10 +6,7 @@ import (
 	"io"
 	"log/slog"
 	"maps"
-	"os/exec"
-	"regexp"
 	"slices"
-	"sort"
 	"strings"
 	"text/template"
 
@@ -19,15 +16,14 @@ import (
 	. "github.com/jfk9w/consul-publish/internal/listeners"
 )
 
-var lineStart = regexp.MustCompile(`(?m)^`)
-
 type Config struct {
-	KV      string `yaml:"kv"`
-	Service *File  `yaml:"service,omitempty"`
-	Node    *File  `yaml:"node,omitempty"`
-	Exec    string `yaml:"exec"`
-	Auth    string `yaml:"auth,omitempty"`
-	Common  string `yaml:"common,omitempty" doc:"Common Caddyfile directives added to every generated site block"`
+	KV       string `yaml:"kv"`
+	Service  *File  `yaml:"service,omitempty"`
+	Node     *File  `yaml:"node,omitempty"`
+	Exec     string `yaml:"exec"`
+	Auth     string `yaml:"auth,omitempty"`
+	Common   string `yaml:"common,omitempty" doc:"Common Caddyfile directives added to every generated site block"`
+	Tolerant bool   `yaml:"tolerant,omitempty" doc:"Skip and log services whose templates fail to render instead of failing the whole update"`
 }
 
 type Listener struct {
@@ -40,6 +36,11 @@ func New(cfg Config) *Listener {
 	}
 }
 
+// Name returns the listener name used in logs and metrics.
+func (l *Listener) Name() string {
+	return "caddy"
+}
+
 func (l *Listener) KV() []string {
 	return []string{
 		l.cfg.KV,
@@ -52,26 +53,13 @@ func (l *Listener) Notify(ctx context.Context, state *consul.State) (err error)
 		return errors.Errorf("%s is not a folder", l.cfg.KV)
 	}
 
-	log := slog.With("listener", "caddy", "self", state.Self)
-	self := state.Nodes[state.Self]
-	services := make(map[string][]Instance)
+	log := slog.With("listener", l.Name(), "self", state.Self)
+	services := GetInstances(state)
 	instanceCount := 0
-	for _, node := range state.Nodes {
-		for _, service := range node.Services {
-			instanceCount++
-			service.Address = GetLocalAddress(self, service)
-			services[service.ID] = append(services[service.ID], Instance{
-				Node:    node,
-				Service: service,
-			})
-		}
-	}
-
 	for _, instances := range services {
-		sort.Slice(instances, func(i, j int) bool {
-			return instances[i].Service.Address < instances[j].Service.Address
-		})
+		instanceCount += len(instances)
 	}
+
 	log.Debug("rendering caddy configuration",
 		"nodes", len(state.Nodes),
 		"service_ids", len(services),
@@ -79,9 +67,12 @@ func (l *Listener) Notify(ctx context.Context, state *consul.State) (err error)
 		"definitions", len(definitions),
 	)
 
+	errs := NewServiceErrors("caddy", l.cfg.Tolerant)
+	defer errs.Report()
+
 	var changedService bool
 	if l.cfg.Service != nil {
-		changedService, err = l.writeService(state, services, maps.Collect(definitions.Values()))
+		changedService, err = l.writeService(state, services, maps.Collect(definitions.Values()), errs)
 		if err != nil {
 			return errors.Wrap(err, "write Service")
 		}
@@ -89,7 +80,7 @@ func (l *Listener) Notify(ctx context.Context, state *consul.State) (err error)
 
 	var changedNode bool
 	if l.cfg.Node != nil {
-		changedNode, err = l.writeNode(state, services, maps.Collect(definitions.Values()))
+		changedNode, err = l.writeNode(state, services, maps.Collect(definitions.Values()), errs)
 		if err != nil {
 			return errors.Wrap(err, "write path")
 		}
@@ -102,8 +93,7 @@ func (l *Listener) Notify(ctx context.Context, state *consul.State) (err error)
 
 	if changedService || changedNode {
 		log.Info("caddy configuration changed, reloading")
-		err := exec.CommandContext(ctx, "sh", "-c", l.cfg.Exec).Run()
-		if err != nil {
+		if err := Exec(ctx, l.Name(), l.cfg.Exec); err != nil {
 			log.Error("failed to reload caddy", "error", err)
 			return errors.Wrap(err, "reload caddy")
 		}
@@ -117,28 +107,36 @@ func (l *Listener) writeNode(
 	state *consul.State,
 	services map[string][]Instance,
 	definitions map[string]consul.Value,
+	errs *ServiceErrors,
 ) (bool, error) {
-	return l.cfg.Node.Write(func(file io.Writer) error {
-		domains := make(map[string][]Instance)
-		for _, id := range slices.Sorted(maps.Keys(services)) {
-			for _, instance := range services[id] {
-				if _, ok := definitions[id]; !ok {
-					continue
-				}
+	domains := make(map[string][]string)
+	for _, id := range slices.Sorted(maps.Keys(services)) {
+		for _, instance := range services[id] {
+			if _, ok := definitions[id]; !ok {
+				continue
+			}
 
-				if !state.InGroup(instance.Service.Meta, PublishPathKey, state.Self) {
-					continue
-				}
+			if !state.InGroup(instance.Service.Meta, PublishPathKey, state.Self) {
+				continue
+			}
 
-				domain, ok := GetDomainName(instance.Node.Meta)
-				if !ok {
-					domain = "http://" + instance.Node.Name
-				}
+			domain, ok := GetDomainName(instance.Node.Meta)
+			if !ok {
+				domain = "http://" + instance.Node.Name
+			}
 
-				domains[domain] = append(domains[domain], instance)
+			block, err := l.render(state, definitions, instance, instance)
+			if err := errs.Handle(id, err); err != nil {
+				return false, err
+			}
+
+			if err == nil {
+				domains[domain] = append(domains[domain], block)
 			}
 		}
+	}
 
+	return l.cfg.Node.Write(func(file io.Writer) error {
 		for i, domain := range slices.Sorted(maps.Keys(domains)) {
 			if i > 0 {
 				if _, err := fmt.Fprintf(file, "\n"); err != nil {
@@ -153,22 +151,8 @@ func (l *Listener) writeNode(
 				return errors.Wrapf(err, "write common block for %s", domain)
 			}
 
-			for _, instance := range domains[domain] {
-				if _, err := fmt.Fprintf(file, "\n"); err != nil {
-					return err
-				}
-
-				id := instance.Service.ID
-				tmpl, err := l.tmpl(state, definitions, instance)
-				if err != nil {
-					return err
-				}
-
-				if err := tmpl.Execute(file, instance); err != nil {
-					return errors.Wrapf(err, "execute template for %s", id)
-				}
-
-				if _, err := fmt.Fprintf(file, "\n"); err != nil {
+			for _, block := range domains[domain] {
+				if _, err := fmt.Fprintf(file, "\n%s\n", block); err != nil {
 					return err
 				}
 			}
@@ -186,33 +170,36 @@ func (l *Listener) writeService(
 	state *consul.State,
 	services map[string][]Instance,
 	definitions map[string]consul.Value,
+	errs *ServiceErrors,
 ) (bool, error) {
-	return l.cfg.Service.Write(func(file io.Writer) error {
-		type entry struct {
-			id        string
-			instances []Instance
-		}
+	// Group rendered service entries by domain, preserving sorted order of IDs.
+	domains := make(map[string][]string)
+	for _, id := range slices.Sorted(maps.Keys(definitions)) {
+		var instances []Instance
+		for _, instance := range services[id] {
+			if len(GetHTTPDomainNames(state, instance.Service.Meta)) == 0 {
+				continue
+			}
 
-		// Group service entries by domain, preserving sorted order of IDs.
-		domains := make(map[string][]entry)
-		for _, id := range slices.Sorted(maps.Keys(definitions)) {
-			var instances []Instance
-			for _, instance := range services[id] {
-				if len(GetHTTPDomainNames(state, instance.Service.Meta)) == 0 {
-					continue
-				}
+			instances = append(instances, instance)
+		}
 
-				instances = append(instances, instance)
-			}
+		if len(instances) == 0 {
+			continue
+		}
 
-			if len(instances) == 0 {
-				continue
-			}
+		block, err := l.render(state, definitions, instances[0], instances)
+		if err := errs.Handle(id, err); err != nil {
+			return false, err
+		}
 
+		if err == nil {
 			domain, _ := GetDomainName(instances[0].Service.Meta)
-			domains[domain] = append(domains[domain], entry{id, instances})
+			domains[domain] = append(domains[domain], block)
 		}
+	}
 
+	return l.cfg.Service.Write(func(file io.Writer) error {
 		for i, domain := range slices.Sorted(maps.Keys(domains)) {
 			if i > 0 {
 				if _, err := fmt.Fprintf(file, "\n"); err != nil {
@@ -227,15 +214,10 @@ func (l *Listener) writeService(
 				return errors.Wrapf(err, "write common block for %s", domain)
 			}
 
-			for _, e := range domains[domain] {
-				tmpl, err := l.tmpl(state, definitions, e.instances[0])
-				if err != nil {
+			for _, block := range domains[domain] {
+				if _, err := fmt.Fprint(file, block); err != nil {
 					return err
 				}
-
-				if err := tmpl.Execute(file, e.instances); err != nil {
-					return errors.Wrapf(err, "execute template for %s", e.id)
-				}
 			}
 
 			if _, err := fmt.Fprintln(file, "\n}"); err != nil {
@@ -247,15 +229,23 @@ func (l *Listener) writeService(
 	})
 }
 
-func (l *Listener) writeCommon(file io.Writer) error {
-	common := strings.TrimSpace(l.cfg.Common)
-	if common == "" {
-		return nil
+// render executes the service template for instance with data.
+func (l *Listener) render(state *consul.State, definitions map[string]consul.Value, instance Instance, data any) (string, error) {
+	tmpl, err := l.tmpl(state, definitions, instance)
+	if err != nil {
+		return "", err
 	}
 
-	common = lineStart.ReplaceAllString(common, "    ")
-	_, err := fmt.Fprintln(file, common)
-	return err
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", errors.Wrapf(err, "execute template for %s", instance.Service.ID)
+	}
+
+	return rendered.String(), nil
+}
+
+func (l *Listener) writeCommon(file io.Writer) error {
+	return WriteIndented(file, l.cfg.Common)
 }
 
 func (l *Listener) auth(state *consul.State, instance Instance, indent int) (string, error) {
@@ -292,7 +282,7 @@ func (l *Listener) tmpl(state *consul.State, definitions map[string]consul.Value
 	}
 
 	definition := strings.Trim(string(definitions[id]), " \n\t\v")
-	definition = lineStart.ReplaceAllString(definition, "    ")
+	definition = Indent(definition)
 	tmpl, err := template.New(id).Delims("[[", "]]").Funcs(funcs).Parse(definition)
 	if err != nil {
 		return nil, errors.Wrapf(err, "parse template for %s", id)
@@ -301,11 +291,6 @@ func (l *Listener) tmpl(state *consul.State, definitions map[string]consul.Value
 	return tmpl, nil
 }
 
-type Instance struct {
-	Node    consul.Node
-	Service consul.Service
-}
-
 func serviceIDs(services []consul.Service) []string {
 	ids := make([]string, 0, len(services))
 	for _, service := range services {
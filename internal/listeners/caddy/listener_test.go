@@ -2,11 +2,15 @@ package caddy
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"os"
 	"strings"
 	"testing"
 
 	"github.com/jfk9w/consul-publish/internal/consul"
+	. "github.com/jfk9w/consul-publish/internal/listeners"
+	"github.com/jfk9w/consul-publish/internal/listeners/listenerstest"
 )
 
 func TestWriteCommon(t *testing.T) {
@@ -171,6 +175,59 @@ func TestForwardAuthFailureStopsTemplateExecution(t *testing.T) {
 	}
 }
 
+func TestNotifyTolerant(t *testing.T) {
+	t.Parallel()
+
+	state := &consul.State{
+		Self: "caddy",
+		Nodes: map[string]consul.Node{
+			"caddy": {
+				Name:    "caddy",
+				Address: "10.0.0.1",
+				Services: []consul.Service{
+					{ID: "app", Address: "10.0.0.2", Port: 8080, Meta: map[string]string{
+						DomainNameKey:  "app.example.com",
+						PublishHTTPKey: "all",
+					}},
+					{ID: "broken", Address: "10.0.0.2", Port: 8081, Meta: map[string]string{
+						DomainNameKey:  "broken.example.com",
+						PublishHTTPKey: "all",
+					}},
+				},
+			},
+		},
+		KV: consul.Folder{"caddy": consul.Folder{
+			"app":    consul.Value("reverse_proxy [[ (index . 0).Service.Address ]]:[[ (index . 0).Service.Port ]]"),
+			"broken": consul.Value("reverse_proxy [[ .Missing ]]"),
+		}},
+	}
+
+	file := listenerstest.File(t, "services.conf")
+	err := New(Config{KV: "caddy", Service: &file, Exec: "true"}).Notify(context.Background(), state)
+	if err == nil || !strings.Contains(err.Error(), "execute template for broken") {
+		t.Fatalf("strict Notify() error = %v, want template error", err)
+	}
+	if _, err := os.Stat(file.Path); !os.IsNotExist(err) {
+		t.Fatalf("strict Notify() wrote configuration: stat error = %v", err)
+	}
+
+	if err := New(Config{KV: "caddy", Service: &file, Exec: "true", Tolerant: true}).Notify(context.Background(), state); err != nil {
+		t.Fatalf("tolerant Notify() error = %v", err)
+	}
+
+	data, err := os.ReadFile(file.Path)
+	if err != nil {
+		t.Fatalf("read configuration: %v", err)
+	}
+
+	want := "app.example.com {\n" +
+		"    reverse_proxy 10.0.0.2:8080\n" +
+		"}\n"
+	if string(data) != want {
+		t.Errorf("configuration = %q, want %q", data, want)
+	}
+}
+
 type errorWriter struct{}
 
 func (errorWriter) Write([]byte) (int, error) {
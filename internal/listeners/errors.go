@@ -0,0 +1,58 @@
+package listeners
+
+import (
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var templateErrors = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "consul_publish_template_errors",
+	Help: "Services skipped by a tolerant listener because their templates failed to render during the last update.",
+}, []string{"listener", "service"})
+
+// Collectors returns the Prometheus collectors maintained by the listeners.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{templateErrors, safeguardBlocked, filesChanged, execRuns, execFailures}
+}
+
+// ServiceErrors collects per-service rendering errors during a single update.
+// In strict mode errors are returned as is; in tolerant mode they are logged
+// and recorded so that the failing services can be skipped.
+type ServiceErrors struct {
+	listener string
+	tolerant bool
+	failed   map[string]error
+}
+
+// NewServiceErrors creates an empty ServiceErrors for the named listener.
+func NewServiceErrors(listener string, tolerant bool) *ServiceErrors {
+	return &ServiceErrors{
+		listener: listener,
+		tolerant: tolerant,
+		failed:   make(map[string]error),
+	}
+}
+
+// Handle returns err in strict mode. In tolerant mode it logs and records err
+// for the service and returns nil, so that the caller skips the service.
+func (e *ServiceErrors) Handle(id string, err error) error {
+	if err == nil || !e.tolerant {
+		return err
+	}
+
+	if _, ok := e.failed[id]; !ok {
+		slog.Error("skipping service", "listener", e.listener, "service", id, "error", err)
+		e.failed[id] = err
+	}
+
+	return nil
+}
+
+// Report replaces the listener's template error metrics with the services that failed during this update.
+func (e *ServiceErrors) Report() {
+	templateErrors.DeletePartialMatch(prometheus.Labels{"listener": e.listener})
+	for id := range e.failed {
+		templateErrors.WithLabelValues(e.listener, id).Set(1)
+	}
+}
@@ -0,0 +1,43 @@
+package listeners
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	execRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "consul_publish_exec_runs_total",
+		Help: "Check and reload commands run by the listeners.",
+	}, []string{"listener"})
+
+	execFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "consul_publish_exec_failures_total",
+		Help: "Check and reload commands run by the listeners that failed.",
+	}, []string{"listener"})
+)
+
+// Exec runs command of the named listener with sh -c. On failure, the command output is included
+// in the error. Runs and failures are counted by listener, as commands may contain secrets.
+func Exec(ctx context.Context, listener, command string) error {
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	execRuns.WithLabelValues(listener).Inc()
+	if err := cmd.Run(); err != nil {
+		execFailures.WithLabelValues(listener).Inc()
+		if text := strings.TrimSpace(output.String()); text != "" {
+			return errors.Wrapf(err, "%s: %s", command, text)
+		}
+
+		return errors.Wrap(err, command)
+	}
+
+	return nil
+}
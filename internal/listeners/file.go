@@ -12,8 +12,14 @@ import (
 	"strconv"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
+var filesChanged = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "consul_publish_files_changed_total",
+	Help: "Files replaced by the listeners because their content changed.",
+}, []string{"path"})
+
 // File describes the target path and ownership settings for an atomically-written file.
 type File struct {
 	Path  string      `yaml:"path"`
@@ -72,9 +78,48 @@ func (f File) Write(writeFn func(file io.Writer) error) (bool, error) {
 	}
 
 	log.Info("updated file")
+	filesChanged.WithLabelValues(f.Path).Inc()
 	return true, nil
 }
 
+// WriteChecked writes content like Write and then runs check against the file in place.
+// When check fails, the previous content is restored (or the file is removed if it did not
+// exist before) and the check error is returned. Check is not run for unchanged files.
+func (f File) WriteChecked(writeFn func(file io.Writer) error, check func() error) (bool, error) {
+	previous, err := os.ReadFile(f.Path)
+	existed := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, errors.Wrap(err, "read previous content")
+	}
+
+	changed, err := f.Write(writeFn)
+	if err != nil || !changed {
+		return changed, err
+	}
+
+	checkErr := check()
+	if checkErr == nil {
+		return true, nil
+	}
+
+	log := slog.With("path", f.Path)
+	log.Warn("file check failed, restoring previous content", "error", checkErr)
+	if existed {
+		_, err = f.Write(func(file io.Writer) error {
+			_, err := file.Write(previous)
+			return err
+		})
+	} else {
+		err = os.Remove(f.Path)
+	}
+
+	if err != nil {
+		return false, errors.Wrapf(err, "restore previous content after failed check: %v", checkErr)
+	}
+
+	return false, errors.Wrap(checkErr, "check file")
+}
+
 func (f File) isSame(tempPath string) (bool, error) {
 	target, err := hashSHA256(f.Path)
 	switch {
@@ -0,0 +1,171 @@
+// Package haproxy generates HAProxy frontend and backend sections from Consul services.
+package haproxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/jfk9w/consul-publish/internal/consul"
+	. "github.com/jfk9w/consul-publish/internal/listeners"
+)
+
+var invalidChars = regexp.MustCompile(`[^A-Za-z0-9_.:-]`)
+
+// Proxy modes supported by the generated frontend.
+const (
+	ModeHTTP = "http" // route on the Host header
+	ModeTCP  = "tcp"  // route on TLS SNI without terminating TLS
+)
+
+// Config holds the HAProxy listener settings.
+type Config struct {
+	File     File   `yaml:"file" doc:"Generated HAProxy configuration file settings"`
+	Name     string `yaml:"name,omitempty" default:"consul-publish" doc:"Name of the generated frontend section"`
+	Bind     string `yaml:"bind,omitempty" default:":80" doc:"Bind directive value of the generated frontend"`
+	Mode     string `yaml:"mode,omitempty" default:"http" doc:"Proxy mode; http routes on the Host header, tcp routes on TLS SNI"`
+	Frontend string `yaml:"frontend,omitempty" doc:"Extra directives added to the generated frontend section"`
+	Backend  string `yaml:"backend,omitempty" doc:"Extra directives added to every generated backend section"`
+	Check    string `yaml:"check,omitempty" doc:"Command that validates the written configuration, haproxy -c -f <path> by default; on failure the previous file is restored"`
+	Exec     string `yaml:"exec" doc:"Command to run after the HAProxy configuration changes"`
+}
+
+// Listener writes HAProxy frontend and backend sections for HTTP-published services.
+type Listener struct {
+	cfg Config
+}
+
+// New creates a Listener with the given configuration.
+func New(cfg Config) *Listener {
+	return &Listener{cfg: cfg}
+}
+
+// Name returns the listener name used in logs and metrics.
+func (l *Listener) Name() string {
+	return "haproxy"
+}
+
+func (l *Listener) KV() []string {
+	return nil
+}
+
+// Notify regenerates the HAProxy configuration, validates it with the check command
+// and runs the reload command when the file changed.
+func (l *Listener) Notify(ctx context.Context, state *consul.State) error {
+	if l.cfg.Mode != ModeHTTP && l.cfg.Mode != ModeTCP {
+		return errors.Errorf("unsupported mode %q: expected %s or %s", l.cfg.Mode, ModeHTTP, ModeTCP)
+	}
+
+	check := l.cfg.Check
+	if check == "" {
+		check = "haproxy -c -f " + shellQuote(l.cfg.File.Path)
+	}
+
+	log := slog.With("listener", l.Name(), "self", state.Self)
+	changed, err := l.cfg.File.WriteChecked(
+		func(file io.Writer) error { return l.write(state, file) },
+		func() error { return Exec(ctx, l.Name(), check) },
+	)
+	if err != nil {
+		return errors.Wrap(err, "write HAProxy configuration")
+	}
+
+	log.Debug("rendered HAProxy configuration", "changed", changed)
+	if changed && l.cfg.Exec != "" {
+		log.Info("HAProxy configuration changed, reloading")
+		if err := Exec(ctx, l.Name(), l.cfg.Exec); err != nil {
+			log.Error("failed to reload HAProxy", "error", err)
+			return errors.Wrap(err, "reload HAProxy")
+		}
+		log.Info("HAProxy reloaded")
+	}
+
+	return nil
+}
+
+func (l *Listener) write(state *consul.State, file io.Writer) error {
+	services := GetInstances(state)
+	backends := make(map[string][]Instance)
+	for id, instances := range services {
+		for _, instance := range instances {
+			if instance.Service.Port == 0 {
+				continue
+			}
+
+			if len(GetHTTPDomainNames(state, instance.Service.Meta)) == 0 {
+				continue
+			}
+
+			backends[id] = append(backends[id], instance)
+		}
+	}
+
+	ids := slices.Sorted(maps.Keys(backends))
+	if _, err := fmt.Fprintf(file, "frontend %s\n    bind %s\n    mode %s\n", l.cfg.Name, l.cfg.Bind, l.cfg.Mode); err != nil {
+		return errors.Wrap(err, "write frontend")
+	}
+
+	if l.cfg.Mode == ModeTCP {
+		if _, err := fmt.Fprint(file,
+			"    tcp-request inspect-delay 5s\n",
+			"    tcp-request content accept if { req_ssl_hello_type 1 }\n",
+		); err != nil {
+			return errors.Wrap(err, "write frontend")
+		}
+	}
+
+	if err := WriteIndented(file, l.cfg.Frontend); err != nil {
+		return errors.Wrap(err, "write frontend directives")
+	}
+
+	fetch := "hdr(host),field(1,:)"
+	if l.cfg.Mode == ModeTCP {
+		fetch = "req_ssl_sni"
+	}
+
+	for _, id := range ids {
+		names := GetHostNames(backends[id][0].Service.Meta)
+		if len(names) == 0 {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(file, "    use_backend %s if { %s -i %s }\n", backendName(id), fetch, strings.Join(names, " ")); err != nil {
+			return errors.Wrapf(err, "write rule for %s", id)
+		}
+	}
+
+	for _, id := range ids {
+		if _, err := fmt.Fprintf(file, "\nbackend %s\n    mode %s\n", backendName(id), l.cfg.Mode); err != nil {
+			return errors.Wrapf(err, "write backend for %s", id)
+		}
+
+		if err := WriteIndented(file, l.cfg.Backend); err != nil {
+			return errors.Wrapf(err, "write backend directives for %s", id)
+		}
+
+		for _, instance := range backends[id] {
+			if _, err := fmt.Fprintf(file, "    server %s %s:%d check\n",
+				backendName(instance.Node.Name), instance.Service.Address, instance.Service.Port,
+			); err != nil {
+				return errors.Wrapf(err, "write server for %s", id)
+			}
+		}
+	}
+
+	return nil
+}
+
+func backendName(id string) string {
+	return invalidChars.ReplaceAllString(id, "_")
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
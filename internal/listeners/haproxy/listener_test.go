@@ -0,0 +1,153 @@
+package haproxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jfk9w/consul-publish/internal/consul"
+	"github.com/jfk9w/consul-publish/internal/listeners"
+	"github.com/jfk9w/consul-publish/internal/listeners/listenerstest"
+)
+
+func testState() *consul.State {
+	return &consul.State{
+		Self: "edge",
+		Nodes: map[string]consul.Node{
+			"edge": {Name: "edge", Address: "10.0.0.1"},
+			"node-a": {
+				Name: "node-a", Address: "10.0.0.2",
+				Services: []consul.Service{
+					{
+						ID: "app", Address: "10.0.0.2", Port: 8080,
+						Meta: map[string]string{
+							listeners.DomainNameKey:  "https://app.example.com www.example.com:8443",
+							listeners.PublishHTTPKey: "all",
+						},
+					},
+					{
+						ID: "hidden", Address: "10.0.0.2", Port: 8081,
+						Meta: map[string]string{
+							listeners.DomainNameKey:  "hidden.example.com",
+							listeners.PublishHTTPKey: "node-a",
+						},
+					},
+				},
+			},
+			"node-b": {
+				Name: "node-b", Address: "10.0.0.3",
+				Services: []consul.Service{{
+					ID: "app", Address: "10.0.0.3", Port: 8080,
+					Meta: map[string]string{
+						listeners.DomainNameKey:  "https://app.example.com www.example.com:8443",
+						listeners.PublishHTTPKey: "all",
+					},
+				}},
+			},
+		},
+	}
+}
+
+func TestWriteHTTP(t *testing.T) {
+	t.Parallel()
+
+	var got bytes.Buffer
+	listener := New(Config{Name: "web", Bind: ":80", Mode: ModeHTTP, Backend: "balance roundrobin"})
+	if err := listener.write(testState(), &got); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+
+	want := "frontend web\n" +
+		"    bind :80\n" +
+		"    mode http\n" +
+		"    use_backend app if { hdr(host),field(1,:) -i app.example.com www.example.com }\n" +
+		"\n" +
+		"backend app\n" +
+		"    mode http\n" +
+		"    balance roundrobin\n" +
+		"    server node-a 10.0.0.2:8080 check\n" +
+		"    server node-b 10.0.0.3:8080 check\n"
+	if got.String() != want {
+		t.Errorf("write() = %q, want %q", got.String(), want)
+	}
+}
+
+func TestWriteTCP(t *testing.T) {
+	t.Parallel()
+
+	var got bytes.Buffer
+	listener := New(Config{Name: "tls", Bind: ":443", Mode: ModeTCP})
+	if err := listener.write(testState(), &got); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+
+	for _, part := range []string{
+		"    tcp-request content accept if { req_ssl_hello_type 1 }\n",
+		"    use_backend app if { req_ssl_sni -i app.example.com www.example.com }\n",
+		"backend app\n    mode tcp\n",
+	} {
+		if !strings.Contains(got.String(), part) {
+			t.Errorf("write() = %q, want it to contain %q", got.String(), part)
+		}
+	}
+}
+
+func TestNotifyRejectsUnknownMode(t *testing.T) {
+	t.Parallel()
+
+	err := New(Config{Mode: "udp"}).Notify(context.Background(), testState())
+	if err == nil || !strings.Contains(err.Error(), `unsupported mode "udp"`) {
+		t.Fatalf("Notify() error = %v, want unsupported mode error", err)
+	}
+}
+
+func TestNotifyRestoresFileWhenCheckFails(t *testing.T) {
+	t.Parallel()
+
+	file := listenerstest.File(t, "haproxy.cfg")
+	marker := filepath.Join(filepath.Dir(file.Path), "reloaded")
+	listener := New(Config{
+		File:  file,
+		Name:  "web",
+		Bind:  ":80",
+		Mode:  ModeHTTP,
+		Check: "false",
+		Exec:  fmt.Sprintf("touch %q", marker),
+	})
+
+	if err := listener.Notify(context.Background(), testState()); err == nil {
+		t.Fatal("Notify() error = nil, want check error")
+	}
+	if _, err := os.Stat(file.Path); !os.IsNotExist(err) {
+		t.Errorf("configuration exists after failed check: stat error = %v", err)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Errorf("reload command ran after failed check: stat error = %v", err)
+	}
+}
+
+func TestNotifyReloadsAfterCheck(t *testing.T) {
+	t.Parallel()
+
+	file := listenerstest.File(t, "haproxy.cfg")
+	marker := filepath.Join(filepath.Dir(file.Path), "reloaded")
+	listener := New(Config{
+		File:  file,
+		Name:  "web",
+		Bind:  ":80",
+		Mode:  ModeHTTP,
+		Check: fmt.Sprintf("grep -q 'backend app' %q", file.Path),
+		Exec:  fmt.Sprintf("touch %q", marker),
+	})
+
+	if err := listener.Notify(context.Background(), testState()); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Fatalf("reload marker after changed configuration: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package homepage
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+var errorLine = regexp.MustCompile(`\bline (\d+)\b`)
+
+// document is a services.yaml being built. It remembers the service ID that produced
+// each line so that parse errors can be attributed to a service.
+type document struct {
+	text   strings.Builder
+	owners []string
+}
+
+// line appends a formatted line (which may itself span several lines) produced by owner.
+// Group headers and separators have an empty owner.
+func (d *document) line(owner string, format string, args ...any) {
+	text := fmt.Sprintf(format, args...)
+	d.text.WriteString(text)
+	d.text.WriteByte('\n')
+	for range strings.Count(text, "\n") + 1 {
+		d.owners = append(d.owners, owner)
+	}
+}
+
+// check parses the document and attributes a parse error to the service that produced the failing line.
+func (d *document) check() error {
+	var node yaml.Node
+	err := yaml.Unmarshal([]byte(d.text.String()), &node)
+	if err == nil {
+		return nil
+	}
+
+	if match := errorLine.FindStringSubmatch(err.Error()); match != nil {
+		line, _ := strconv.Atoi(match[1])
+		if line > 0 && line <= len(d.owners) && d.owners[line-1] != "" {
+			return errors.Wrapf(err, "invalid services.yaml content for %s", d.owners[line-1])
+		}
+	}
+
+	return errors.Wrap(err, "invalid services.yaml content")
+}
+
+// checkFragment verifies that a YAML fragment is a node of the given kind, so that it can be
+// indented under an entry name. Line numbers in errors are relative to the fragment.
+func checkFragment(id, content string, kind yaml.Kind) error {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &node); err != nil {
+		return errors.Wrapf(err, "invalid YAML for %s", id)
+	}
+
+	if len(node.Content) == 0 {
+		return nil
+	}
+
+	if value := node.Content[0]; value.Kind != kind {
+		return errors.Errorf("invalid YAML for %s: line %d: expected %s, got %s", id, value.Line, kindName(kind), kindName(value.Kind))
+	}
+
+	return nil
+}
+
+func kindName(kind yaml.Kind) string {
+	switch kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a sequence"
+	case yaml.ScalarNode:
+		return "a scalar"
+	case yaml.AliasNode:
+		return "an alias"
+	default:
+		return "a document"
+	}
+}
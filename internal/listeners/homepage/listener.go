@@ -1,17 +1,19 @@
-// Package homepage generates Homepage's services.yaml from Consul services and KV templates.
+// Package homepage generates Homepage's services.yaml, settings.yaml and bookmarks.yaml
+// from Consul services and KV templates.
 package homepage
 
 import (
+	"cmp"
 	"context"
-	"fmt"
+	stderrors "errors"
 	"io"
 	"log/slog"
 	"maps"
-	"os/exec"
 	"slices"
-	"sort"
+	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
@@ -20,155 +22,535 @@ import (
 	. "github.com/jfk9w/consul-publish/internal/listeners"
 )
 
+// Dashboard configures a single Homepage instance: its output files, reload command
+// and the service metadata key that selects the services shown on it.
+type Dashboard struct {
+	Name      string     `yaml:"name,omitempty" doc:"Dashboard name used in logs and metrics, required and unique for additional dashboards; a named dashboard selects services with publish-homepage-<name> by default"`
+	Key       string     `yaml:"key,omitempty" doc:"Service metadata key with the group selector for this dashboard, publish-homepage or publish-homepage-<name> by default"`
+	Exec      string     `yaml:"exec" doc:"Command to run after the Homepage configuration changes"`
+	Services  File       `yaml:"services" doc:"Homepage services.yaml output file settings"`
+	Groups    []string   `yaml:"groups,omitempty" doc:"Homepage group order; unlisted groups follow ordered by the lowest homepage-weight of their services, then by name"`
+	Settings  *Settings  `yaml:"settings,omitempty" doc:"Homepage settings.yaml generation settings"`
+	Bookmarks *Bookmarks `yaml:"bookmarks,omitempty" doc:"Homepage bookmarks.yaml generation settings"`
+}
+
+// Config holds the Homepage listener settings. The inline dashboard is generated when its
+// services file path is set; Dashboards adds further instances sharing the same KV templates.
 type Config struct {
-	KV       string `yaml:"kv" doc:"Consul KV prefix that holds Homepage service templates"`
-	Exec     string `yaml:"exec" doc:"Command to run after the Homepage configuration changes"`
-	Services File   `yaml:"services" doc:"Homepage services.yaml output file settings"`
+	KV        string  `yaml:"kv" doc:"Consul KV prefix that holds Homepage service templates"`
+	Tolerant  bool    `yaml:"tolerant,omitempty" doc:"Skip and log services whose templates or metadata fail to render instead of failing the whole update"`
+	Secrets   Secrets `yaml:"secrets,omitempty" doc:"Sources of the secret template function"`
+	Dashboard `yaml:",inline"`
+
+	Dashboards []Dashboard `yaml:"dashboards,omitempty" doc:"Additional Homepage dashboards, each with its own output files, reload command and selector key"`
 }
 
 type Listener struct {
-	cfg Config
+	cfg        Config
+	dashboards []*dashboard
+}
+
+// New creates a Listener. Every entry of Dashboards needs a unique name, as the name
+// identifies the dashboard in logs and metrics and selects its services by default.
+func New(cfg Config) (*Listener, error) {
+	l := &Listener{cfg: cfg}
+	names := make(map[string]bool)
+	if cfg.Services.Path != "" {
+		l.dashboards = append(l.dashboards, &dashboard{Dashboard: cfg.Dashboard, tolerant: cfg.Tolerant, secrets: cfg.Secrets})
+		names[cfg.Name] = true
+	}
+
+	for _, d := range cfg.Dashboards {
+		if d.Name == "" || names[d.Name] {
+			return nil, errors.Errorf("dashboard name %q is empty or not unique", d.Name)
+		}
+
+		names[d.Name] = true
+		l.dashboards = append(l.dashboards, &dashboard{Dashboard: d, tolerant: cfg.Tolerant, secrets: cfg.Secrets})
+	}
+
+	return l, nil
 }
 
-func New(cfg Config) *Listener {
-	return &Listener{cfg: cfg}
+// Name returns the listener name used in logs and metrics. Every dashboard records its
+// notifications under its own name, homepage or homepage/<name>.
+func (l *Listener) Name() string {
+	return "homepage"
 }
 
+// MultiTarget marks the listener as recording the notifications of its dashboards.
+func (l *Listener) MultiTarget() {}
+
 func (l *Listener) KV() []string {
-	return []string{l.cfg.KV}
+	kv := []string{l.cfg.KV}
+	add := func(prefix string) {
+		if prefix != "" && !slices.Contains(kv, prefix) {
+			kv = append(kv, prefix)
+		}
+	}
+
+	for _, d := range l.dashboards {
+		if d.Settings != nil {
+			add(d.Settings.KV)
+		}
+
+		if d.Bookmarks != nil {
+			add(d.Bookmarks.KV)
+		}
+	}
+
+	return kv
 }
 
+// Notify writes the files of every dashboard. A dashboard that fails does not prevent
+// the others from being written; the errors of all dashboards are returned together.
 func (l *Listener) Notify(ctx context.Context, state *consul.State) error {
 	definitions, ok := state.KV.Get(l.cfg.KV).(consul.Folder)
 	if !ok {
 		return errors.Errorf("%s is not a folder", l.cfg.KV)
 	}
 
-	changed, err := l.cfg.Services.Write(func(file io.Writer) error {
-		return l.write(state, file, maps.Collect(definitions.Values()))
+	templates := maps.Collect(definitions.Values())
+	var errs []error
+	for _, d := range l.dashboards {
+		start := time.Now()
+		err := d.notify(ctx, state, templates)
+		consul.ObserveNotify(d.listener(), start, err)
+		if err != nil {
+			if d.Name != "" {
+				err = errors.Wrapf(err, "dashboard %s", d.Name)
+			}
+
+			errs = append(errs, err)
+		}
+	}
+
+	return stderrors.Join(errs...)
+}
+
+// dashboard generates the files of a single Homepage instance.
+type dashboard struct {
+	Dashboard
+	tolerant bool
+	secrets  Secrets
+}
+
+// key returns the service metadata key that selects the services shown on the dashboard.
+func (d *dashboard) key() string {
+	if d.Key != "" {
+		return d.Key
+	}
+
+	if d.Name != "" {
+		return PublishHomepageKey + "-" + d.Name
+	}
+
+	return PublishHomepageKey
+}
+
+// listener returns the listener name used in logs and metrics.
+func (d *dashboard) listener() string {
+	if d.Name != "" {
+		return "homepage/" + d.Name
+	}
+
+	return "homepage"
+}
+
+func (d *dashboard) notify(ctx context.Context, state *consul.State, definitions map[string]consul.Value) error {
+	errs := NewServiceErrors(d.listener(), d.tolerant)
+	defer errs.Report()
+
+	root, err := d.place(state, definitions, errs)
+	if err != nil {
+		return err
+	}
+
+	changed, err := d.Services.Write(func(file io.Writer) error {
+		return d.writeServices(file, root)
 	})
 	if err != nil {
 		return errors.Wrap(err, "write Homepage services configuration")
 	}
 
-	slog.Debug("rendered Homepage configuration", "listener", "homepage", "self", state.Self, "changed", changed)
-	if changed && l.cfg.Exec != "" {
-		slog.Info("Homepage configuration changed, reloading", "listener", "homepage")
-		if err := exec.CommandContext(ctx, "sh", "-c", l.cfg.Exec).Run(); err != nil {
-			slog.Error("failed to reload Homepage", "listener", "homepage", "error", err)
+	if d.Settings != nil {
+		layouts, err := values(state, d.Settings.KV)
+		if err != nil {
+			return err
+		}
+
+		if err := checkValues(d.Settings.KV, layouts, yaml.MappingNode, errs); err != nil {
+			return err
+		}
+
+		settingsChanged, err := d.Settings.File.Write(func(file io.Writer) error {
+			return d.writeSettings(file, d.groupNames(root), layouts)
+		})
+		if err != nil {
+			return errors.Wrap(err, "write Homepage settings configuration")
+		}
+
+		changed = changed || settingsChanged
+	}
+
+	if d.Bookmarks != nil {
+		bookmarks, err := values(state, d.Bookmarks.KV)
+		if err != nil {
+			return err
+		}
+
+		if err := checkValues(d.Bookmarks.KV, bookmarks, yaml.SequenceNode, errs); err != nil {
+			return err
+		}
+
+		bookmarksChanged, err := d.Bookmarks.File.Write(func(file io.Writer) error {
+			return d.writeBookmarks(file, bookmarks)
+		})
+		if err != nil {
+			return errors.Wrap(err, "write Homepage bookmarks configuration")
+		}
+
+		changed = changed || bookmarksChanged
+	}
+
+	log := slog.With("listener", d.listener())
+	log.Debug("rendered Homepage configuration", "self", state.Self, "changed", changed)
+	if changed && d.Exec != "" {
+		log.Info("Homepage configuration changed, reloading")
+		if err := Exec(ctx, d.listener(), d.Exec); err != nil {
+			log.Error("failed to reload Homepage", "error", err)
 			return errors.Wrap(err, "reload Homepage")
 		}
-		slog.Info("Homepage reloaded", "listener", "homepage")
+		log.Info("Homepage reloaded")
 	}
 
 	return nil
 }
 
-type Instance struct {
-	Node    consul.Node
-	Service consul.Service
-}
-
 type placement struct {
 	name      string
 	serviceID string
+	weight    int
 	instances []Instance
+	content   string
 }
 
-func (l *Listener) write(state *consul.State, file io.Writer, definitions map[string]consul.Value) error {
-	self := state.Nodes[state.Self]
-	services := make(map[string][]Instance)
-	for _, node := range state.Nodes {
-		for _, service := range node.Services {
-			if _, ok := definitions[service.ID]; !ok {
-				continue
-			}
-			if !state.InGroup(service.Meta, PublishHomepageKey, state.Self) {
-				continue
-			}
+// group is a Homepage group built from homepage-path. The root group only holds top-level groups.
+type group struct {
+	path    string
+	name    string
+	weight  int
+	entries []placement
+	groups  map[string]*group
+}
+
+func (g *group) child(name string) *group {
+	if g.groups == nil {
+		g.groups = make(map[string]*group)
+	}
 
-			service.Address = GetLocalAddress(self, service)
-			services[service.ID] = append(services[service.ID], Instance{Node: node, Service: service})
+	child, ok := g.groups[name]
+	if !ok {
+		child = &group{path: strings.TrimPrefix(g.path+"/"+name, "/"), name: name}
+		g.groups[name] = child
+	}
+
+	return child
+}
+
+// updateWeight sets the weight of g and its subgroups to the lowest weight of their entries.
+func (g *group) updateWeight() int {
+	first := true
+	update := func(weight int) {
+		if first || weight < g.weight {
+			g.weight = weight
+			first = false
 		}
 	}
 
-	for _, instances := range services {
-		sort.Slice(instances, func(i, j int) bool {
-			if instances[i].Service.Address == instances[j].Service.Address {
-				return instances[i].Node.Name < instances[j].Node.Name
+	for _, entry := range g.entries {
+		update(entry.weight)
+	}
+
+	for _, child := range g.groups {
+		update(child.updateWeight())
+	}
+
+	return g.weight
+}
+
+// item is either an entry or a subgroup of a group, in display order.
+type item struct {
+	entry *placement
+	group *group
+}
+
+// place renders the services published to Homepage into a tree of groups built from homepage-path.
+// Services that fail to render are passed to errs and left out of the tree when it tolerates them.
+func (d *dashboard) place(state *consul.State, definitions map[string]consul.Value, errs *ServiceErrors) (*group, error) {
+	services := make(map[string][]Instance)
+	for id, instances := range GetInstances(state) {
+		_, templated := definitions[id]
+		for _, instance := range instances {
+			if !templated && !hasMetaEntry(instance.Service.Meta) {
+				continue
 			}
-			return instances[i].Service.Address < instances[j].Service.Address
-		})
+
+			if state.InGroup(instance.Service.Meta, d.key(), state.Self) {
+				services[id] = append(services[id], instance)
+			}
+		}
 	}
 
-	groups := make(map[string][]placement)
+	root := new(group)
 	seen := make(map[string]struct{})
 	for _, id := range slices.Sorted(maps.Keys(services)) {
 		instances := services[id]
 		for _, instance := range instances {
-			value := instance.Service.Meta[HomepagePathKey]
-			group, name, ok := strings.Cut(value, "/")
-			group = strings.TrimSpace(group)
-			name = strings.TrimSpace(name)
-			if !ok || group == "" || name == "" {
-				return errors.Errorf("invalid %s value %q for service %s: expected group/service-name", HomepagePathKey, value, id)
+			path, entry, err := placeInstance(id, instance, instances)
+			if err == nil {
+				key := strings.Join(append(path, entry.name, id), "\x00")
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+
+				entry.content, err = render(entry, definitions, d.secrets)
+			}
+
+			if err := errs.Handle(id, err); err != nil {
+				return nil, err
 			}
 
-			key := group + "\x00" + name + "\x00" + id
-			if _, ok := seen[key]; ok {
+			if err != nil {
 				continue
 			}
-			seen[key] = struct{}{}
-			groups[group] = append(groups[group], placement{name: name, serviceID: id, instances: instances})
+
+			parent := root
+			for _, name := range path {
+				parent = parent.child(name)
+			}
+
+			parent.entries = append(parent.entries, entry)
 		}
 	}
 
-	for _, entries := range groups {
-		sort.Slice(entries, func(i, j int) bool {
-			if entries[i].name == entries[j].name {
-				return entries[i].serviceID < entries[j].serviceID
-			}
-			return entries[i].name < entries[j].name
-		})
+	root.updateWeight()
+	return root, nil
+}
+
+// placeInstance returns the group path and the unrendered entry for a service instance.
+func placeInstance(id string, instance Instance, instances []Instance) ([]string, placement, error) {
+	path, name, err := parsePath(instance.Service.Meta[HomepagePathKey])
+	if err != nil {
+		return nil, placement{}, errors.Wrapf(err, "service %s", id)
+	}
+
+	weight, err := getWeight(instance.Service.Meta)
+	if err != nil {
+		return nil, placement{}, errors.Wrapf(err, "service %s", id)
+	}
+
+	return path, placement{name: name, serviceID: id, weight: weight, instances: instances}, nil
+}
+
+// parsePath splits a homepage-path value into its group path and the entry name.
+func parsePath(value string) ([]string, string, error) {
+	segments := strings.Split(value, "/")
+	for i, segment := range segments {
+		segments[i] = strings.TrimSpace(segment)
 	}
 
-	for groupIndex, group := range slices.Sorted(maps.Keys(groups)) {
+	if len(segments) < 2 || slices.Contains(segments, "") {
+		return nil, "", errors.Errorf("invalid %s value %q: expected group/service-name or group/subgroup/service-name", HomepagePathKey, value)
+	}
+
+	return segments[:len(segments)-1], segments[len(segments)-1], nil
+}
+
+// writeServices writes the services.yaml document for the group tree. The document is
+// parsed before anything is written, so a broken entry never reaches the file.
+func (d *dashboard) writeServices(file io.Writer, root *group) error {
+	doc := new(document)
+	for groupIndex, item := range d.items(root) {
 		if groupIndex > 0 {
-			if _, err := fmt.Fprintln(file); err != nil {
-				return err
-			}
+			doc.line("", "")
 		}
 
-		if _, err := fmt.Fprintf(file, "- %s:\n", yamlScalar(group)); err != nil {
-			return err
+		doc.line("", "- %s:", yamlScalar(item.group.name))
+		d.writeGroup(doc, item.group, 1)
+	}
+
+	if err := doc.check(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(file, doc.text.String())
+	return err
+}
+
+// writeGroup writes the entries and subgroups of g as a YAML list indented by depth levels.
+func (d *dashboard) writeGroup(doc *document, g *group, depth int) {
+	indent := strings.Repeat("    ", depth)
+	for _, item := range d.items(g) {
+		if item.group != nil {
+			doc.line("", "%s- %s:", indent, yamlScalar(item.group.name))
+			d.writeGroup(doc, item.group, depth+1)
+			continue
 		}
 
-		for _, entry := range groups[group] {
-			if _, err := fmt.Fprintf(file, "    - %s:\n", yamlScalar(entry.name)); err != nil {
-				return err
-			}
+		entry := *item.entry
+		doc.line(entry.serviceID, "%s- %s:", indent, yamlScalar(entry.name))
+		if content := entry.content; content != "" {
+			content = strings.ReplaceAll(content, "\n", "\n"+indent+"    ")
+			doc.line(entry.serviceID, "%s    %s", indent, content)
+		}
+	}
+}
 
-			tmpl, err := template.New(entry.serviceID).Delims("[[", "]]").Parse(strings.TrimSpace(string(definitions[entry.serviceID])))
-			if err != nil {
-				return errors.Wrapf(err, "parse template for %s", entry.serviceID)
-			}
+// groupNames returns the names of the top-level groups in display order.
+func (d *dashboard) groupNames(root *group) []string {
+	var names []string
+	for _, item := range d.items(root) {
+		names = append(names, item.group.name)
+	}
 
-			var rendered strings.Builder
-			if err := tmpl.Execute(&rendered, entry.instances); err != nil {
-				return errors.Wrapf(err, "execute template for %s", entry.serviceID)
-			}
+	return names
+}
 
-			content := strings.TrimSpace(rendered.String())
-			if content != "" {
-				content = strings.ReplaceAll(content, "\n", "\n        ")
-				if _, err := fmt.Fprintf(file, "        %s\n", content); err != nil {
-					return err
-				}
+// items returns the entries and subgroups of g in display order: groups listed in
+// Config.Groups (by path) come first, then everything is ordered by the lowest weight,
+// then by name. Entries precede subgroups with the same name, and service IDs break ties.
+func (d *dashboard) items(g *group) []item {
+	items := make([]item, 0, len(g.entries)+len(g.groups))
+	for i := range g.entries {
+		items = append(items, item{entry: &g.entries[i]})
+	}
+
+	for _, child := range g.groups {
+		items = append(items, item{group: child})
+	}
+
+	position := d.groupPosition()
+	key := func(item item) (int, int, string, int, string) {
+		if item.group != nil {
+			return position(item.group.path), item.group.weight, item.group.name, 1, ""
+		}
+
+		return len(d.Groups), item.entry.weight, item.entry.name, 0, item.entry.serviceID
+	}
+
+	slices.SortFunc(items, func(a, b item) int {
+		ap, aw, an, ak, aid := key(a)
+		bp, bw, bn, bk, bid := key(b)
+		return cmp.Or(
+			cmp.Compare(ap, bp),
+			cmp.Compare(aw, bw),
+			cmp.Compare(an, bn),
+			cmp.Compare(ak, bk),
+			cmp.Compare(aid, bid),
+		)
+	})
+
+	return items
+}
+
+// groupPosition returns a function that maps group paths to their index in Config.Groups.
+// Unlisted groups share the position after the last listed one.
+func (d *dashboard) groupPosition() func(group string) int {
+	positions := make(map[string]int, len(d.Groups))
+	for i, group := range d.Groups {
+		if _, ok := positions[group]; !ok {
+			positions[group] = i
+		}
+	}
+
+	return func(group string) int {
+		if i, ok := positions[group]; ok {
+			return i
+		}
+		return len(d.Groups)
+	}
+}
+
+func getWeight(meta map[string]string) (int, error) {
+	value := strings.TrimSpace(meta[HomepageWeightKey])
+	if value == "" {
+		return 0, nil
+	}
+
+	weight, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.Errorf("invalid %s value %q: expected integer", HomepageWeightKey, value)
+	}
+
+	return weight, nil
+}
+
+// render returns the Homepage entry body for the placement: the KV template when one exists
+// for the service ID, or an entry built from service metadata otherwise. Rendered templates
+// must be YAML mappings. Templates can read local secrets with [[ secret "name" ]].
+func render(entry placement, definitions map[string]consul.Value, secrets Secrets) (string, error) {
+	definition, ok := definitions[entry.serviceID]
+	if !ok {
+		data, err := yaml.Marshal(newMetaEntry(entry.instances[0].Service.Meta))
+		if err != nil {
+			return "", errors.Wrapf(err, "marshal entry for %s", entry.serviceID)
+		}
+
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	tmpl, err := template.New(entry.serviceID).
+		Delims("[[", "]]").
+		Funcs(template.FuncMap{"secret": secrets.scalar}).
+		Parse(strings.TrimSpace(string(definition)))
+	if err != nil {
+		return "", errors.Wrapf(err, "parse template for %s", entry.serviceID)
+	}
+
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, entry.instances); err != nil {
+		return "", errors.Wrapf(err, "execute template for %s", entry.serviceID)
+	}
+
+	content := strings.TrimSpace(rendered.String())
+	if err := checkFragment(entry.serviceID, content, yaml.MappingNode); err != nil {
+		return "", err
+	}
+
+	return content, nil
+}
+
+// metaEntry is a Homepage service entry built from service metadata.
+type metaEntry struct {
+	Icon        string `yaml:"icon,omitempty"`
+	Href        string `yaml:"href,omitempty"`
+	Description string `yaml:"description,omitempty"`
+}
+
+func newMetaEntry(meta map[string]string) metaEntry {
+	href := strings.TrimSpace(meta[HomepageHrefKey])
+	if href == "" {
+		value, _ := GetDomainName(meta)
+		if fields := strings.Fields(value); len(fields) > 0 {
+			href = fields[0]
+			if !strings.Contains(href, "://") {
+				href = "https://" + href
 			}
 		}
 	}
 
-	return nil
+	return metaEntry{
+		Icon:        strings.TrimSpace(meta[HomepageIconKey]),
+		Href:        href,
+		Description: strings.TrimSpace(meta[HomepageDescriptionKey]),
+	}
+}
+
+// hasMetaEntry reports whether a service without a KV template can be placed on Homepage.
+func hasMetaEntry(meta map[string]string) bool {
+	return meta[HomepagePathKey] != "" && newMetaEntry(meta) != metaEntry{}
 }
 
 func yamlScalar(value string) string {
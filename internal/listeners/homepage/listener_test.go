@@ -12,6 +12,7 @@ import (
 
 	"github.com/jfk9w/consul-publish/internal/consul"
 	"github.com/jfk9w/consul-publish/internal/listeners"
+	"github.com/jfk9w/consul-publish/internal/listeners/listenerstest"
 	"gopkg.in/yaml.v3"
 )
 
@@ -29,14 +30,16 @@ func TestNotifyReloadsOnlyAfterChange(t *testing.T) {
 
 	dir := t.TempDir()
 	marker := filepath.Join(dir, "reloaded")
-	listener := New(Config{
-		KV:   "homepage",
-		Exec: fmt.Sprintf("touch %q", marker),
-		Services: listeners.File{
-			Path:  filepath.Join(dir, "services.yaml"),
-			Mode:  0o644,
-			User:  currentUser.Username,
-			Group: currentGroup.Name,
+	listener := newListener(t, Config{
+		KV: "homepage",
+		Dashboard: Dashboard{
+			Exec: fmt.Sprintf("touch %q", marker),
+			Services: listeners.File{
+				Path:  filepath.Join(dir, "services.yaml"),
+				Mode:  0o644,
+				User:  currentUser.Username,
+				Group: currentGroup.Name,
+			},
 		},
 	})
 	state := &consul.State{
@@ -108,9 +111,9 @@ func TestWrite(t *testing.T) {
 		"hidden":  []byte("href: http://hidden"),
 	}
 
-	var got bytes.Buffer
-	if err := New(Config{}).write(state, &got, definitions); err != nil {
-		t.Fatalf("write() error = %v", err)
+	got, err := notify(t, Config{}, state, definitions)
+	if err != nil {
+		t.Fatalf("Notify() error = %v", err)
 	}
 
 	want := "- Home:\n" +
@@ -124,12 +127,12 @@ func TestWrite(t *testing.T) {
 		"        href: http://10.0.0.2:3000\n" +
 		"        widget:\n" +
 		"          type: grafana\n"
-	if got.String() != want {
-		t.Errorf("write() = %q, want %q", got.String(), want)
+	if got != want {
+		t.Errorf("Notify() = %q, want %q", got, want)
 	}
 
 	var document any
-	if err := yaml.Unmarshal(got.Bytes(), &document); err != nil {
+	if err := yaml.Unmarshal([]byte(got), &document); err != nil {
 		t.Errorf("generated configuration is not valid YAML: %v", err)
 	}
 }
@@ -153,15 +156,14 @@ func TestWriteUsesLocalAddress(t *testing.T) {
 		},
 	}
 
-	var got bytes.Buffer
-	err := New(Config{}).write(state, &got, map[string]consul.Value{
+	got, err := notify(t, Config{}, state, map[string]consul.Value{
 		"app": []byte("href: http://[[ (index . 0).Service.Address ]]:[[ (index . 0).Service.Port ]]"),
 	})
 	if err != nil {
-		t.Fatalf("write() error = %v", err)
+		t.Fatalf("Notify() error = %v", err)
 	}
-	if !strings.Contains(got.String(), "http://127.0.0.1:8080") {
-		t.Errorf("write() = %q, want local address", got.String())
+	if !strings.Contains(got, "http://127.0.0.1:8080") {
+		t.Errorf("Notify() = %q, want local address", got)
 	}
 }
 
@@ -175,19 +177,18 @@ func TestWriteAllowsSpacesInPathElements(t *testing.T) {
 		}}}},
 	}}
 
-	var got bytes.Buffer
-	err := New(Config{}).write(state, &got, map[string]consul.Value{
+	got, err := notify(t, Config{}, state, map[string]consul.Value{
 		"home-assistant": []byte("href: /"),
 	})
 	if err != nil {
-		t.Fatalf("write() error = %v", err)
+		t.Fatalf("Notify() error = %v", err)
 	}
 
 	want := "- Дом:\n" +
 		"    - Home Assistant:\n" +
 		"        href: /\n"
-	if got.String() != want {
-		t.Errorf("write() = %q, want %q", got.String(), want)
+	if got != want {
+		t.Errorf("Notify() = %q, want %q", got, want)
 	}
 }
 
@@ -201,9 +202,9 @@ func TestWriteRejectsInvalidGroup(t *testing.T) {
 		}}}},
 	}}
 
-	err := New(Config{}).write(state, &bytes.Buffer{}, map[string]consul.Value{"app": []byte("href: /")})
+	_, err := notify(t, Config{}, state, map[string]consul.Value{"app": []byte("href: /")})
 	if err == nil || !strings.Contains(err.Error(), "expected group/service-name") {
-		t.Fatalf("write() error = %v, want invalid group error", err)
+		t.Fatalf("Notify() error = %v, want invalid group error", err)
 	}
 }
 
@@ -217,8 +218,695 @@ func TestWriteTemplateError(t *testing.T) {
 		}}}},
 	}}
 
-	err := New(Config{}).write(state, &bytes.Buffer{}, map[string]consul.Value{"app": []byte("[[")})
+	_, err := notify(t, Config{}, state, map[string]consul.Value{"app": []byte("[[")})
 	if err == nil || !strings.Contains(err.Error(), "parse template for app") {
-		t.Fatalf("write() error = %v, want template parse error", err)
+		t.Fatalf("Notify() error = %v, want template parse error", err)
+	}
+}
+
+func TestWriteRejectsInvalidFragment(t *testing.T) {
+	t.Parallel()
+
+	state := &consul.State{Self: "node", Nodes: map[string]consul.Node{
+		"node": {Name: "node", Services: []consul.Service{{ID: "app", Meta: map[string]string{
+			listeners.HomepagePathKey:    "Apps/App",
+			listeners.PublishHomepageKey: "all",
+		}}}},
+	}}
+
+	for name, tt := range map[string]struct {
+		template string
+		want     string
+	}{
+		"bad indentation": {template: "href: /\n  icon: app.png", want: "invalid YAML for app: yaml: line 2"},
+		"sequence":        {template: "- href: /", want: "invalid YAML for app: line 1: expected a mapping, got a sequence"},
+		"scalar":          {template: "href", want: "invalid YAML for app: line 1: expected a mapping, got a scalar"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := notify(t, Config{}, state, map[string]consul.Value{"app": []byte(tt.template)})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Notify() error = %v, want %q", err, tt.want)
+			}
+			if got != "" {
+				t.Errorf("Notify() wrote %q, want nothing", got)
+			}
+		})
+	}
+}
+
+func TestDocumentCheckNamesService(t *testing.T) {
+	t.Parallel()
+
+	doc := new(document)
+	doc.line("", "- Apps:")
+	doc.line("app", "    - App:")
+	doc.line("app", "        href: /\n          icon: app.png")
+
+	err := doc.check()
+	if err == nil || !strings.Contains(err.Error(), "invalid services.yaml content for app: yaml: line 4") {
+		t.Fatalf("check() error = %v, want error for app", err)
+	}
+}
+
+func TestWriteBuildsEntryFromMeta(t *testing.T) {
+	t.Parallel()
+
+	state := &consul.State{Self: "node", Nodes: map[string]consul.Node{
+		"node": {Name: "node", Services: []consul.Service{
+			{ID: "jellyfin", Meta: map[string]string{
+				listeners.HomepagePathKey:        "Media/Jellyfin",
+				listeners.PublishHomepageKey:     "all",
+				listeners.HomepageIconKey:        "jellyfin.png",
+				listeners.HomepageDescriptionKey: "Movies: and shows",
+				listeners.DomainNameKey:          "jellyfin.example.com media.example.com",
+			}},
+			{ID: "router", Meta: map[string]string{
+				listeners.HomepagePathKey:    "Network/Router",
+				listeners.PublishHomepageKey: "all",
+				listeners.HomepageHrefKey:    "http://192.168.88.1",
+				listeners.DomainNameKey:      "router.example.com",
+			}},
+			{ID: "internal", Meta: map[string]string{
+				listeners.HomepagePathKey:    "Network/Internal",
+				listeners.PublishHomepageKey: "all",
+			}},
+			{ID: "unplaced", Meta: map[string]string{
+				listeners.PublishHomepageKey: "all",
+				listeners.DomainNameKey:      "unplaced.example.com",
+			}},
+		}},
+	}}
+
+	got, err := notify(t, Config{}, state, map[string]consul.Value{})
+	if err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	want := "- Media:\n" +
+		"    - Jellyfin:\n" +
+		"        icon: jellyfin.png\n" +
+		"        href: https://jellyfin.example.com\n" +
+		"        description: 'Movies: and shows'\n" +
+		"\n" +
+		"- Network:\n" +
+		"    - Router:\n" +
+		"        href: http://192.168.88.1\n"
+	if got != want {
+		t.Errorf("Notify() = %q, want %q", got, want)
+	}
+}
+
+func TestWriteTemplateTakesPrecedenceOverMeta(t *testing.T) {
+	t.Parallel()
+
+	state := &consul.State{Self: "node", Nodes: map[string]consul.Node{
+		"node": {Name: "node", Services: []consul.Service{{ID: "app", Meta: map[string]string{
+			listeners.HomepagePathKey:    "Apps/App",
+			listeners.PublishHomepageKey: "all",
+			listeners.HomepageIconKey:    "app.png",
+		}}}},
+	}}
+
+	got, err := notify(t, Config{}, state, map[string]consul.Value{"app": []byte("href: /from-template")})
+	if err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	want := "- Apps:\n" +
+		"    - App:\n" +
+		"        href: /from-template\n"
+	if got != want {
+		t.Errorf("Notify() = %q, want %q", got, want)
+	}
+}
+
+func TestWriteOrdersByWeight(t *testing.T) {
+	t.Parallel()
+
+	service := func(id, path, weight string) consul.Service {
+		meta := map[string]string{
+			listeners.HomepagePathKey:    path,
+			listeners.PublishHomepageKey: "all",
+		}
+		if weight != "" {
+			meta[listeners.HomepageWeightKey] = weight
+		}
+		return consul.Service{ID: id, Meta: meta}
+	}
+
+	state := &consul.State{Self: "node", Nodes: map[string]consul.Node{
+		"node": {Name: "node", Services: []consul.Service{
+			service("grafana", "Infrastructure/Grafana", ""),
+			service("prometheus", "Infrastructure/Prometheus", "-1"),
+			service("jellyfin", "Media/Jellyfin", "5"),
+			service("sonarr", "Media/Sonarr", "5"),
+			service("radarr", "Media/Radarr", "1"),
+			service("wiki", "Docs/Wiki", "10"),
+			service("router", "Network/Router", "-5"),
+		}},
+	}}
+
+	definitions := make(map[string]consul.Value)
+	for _, id := range []string{"grafana", "prometheus", "jellyfin", "sonarr", "radarr", "wiki", "router"} {
+		definitions[id] = []byte("href: /" + id)
+	}
+
+	got, err := notify(t, Config{Dashboard: Dashboard{Groups: []string{"Media"}}}, state, definitions)
+	if err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	var document []map[string][]map[string]any
+	if err := yaml.Unmarshal([]byte(got), &document); err != nil {
+		t.Fatalf("generated configuration is not valid YAML: %v", err)
+	}
+
+	var order []string
+	for _, group := range document {
+		for name, entries := range group {
+			order = append(order, name+":")
+			for _, entry := range entries {
+				for name := range entry {
+					order = append(order, name)
+				}
+			}
+		}
+	}
+
+	want := []string{
+		"Media:", "Radarr", "Jellyfin", "Sonarr",
+		"Network:", "Router",
+		"Infrastructure:", "Prometheus", "Grafana",
+		"Docs:", "Wiki",
+	}
+	if strings.Join(order, " ") != strings.Join(want, " ") {
+		t.Errorf("Notify() order = %v, want %v", order, want)
+	}
+}
+
+func TestWriteRejectsInvalidWeight(t *testing.T) {
+	t.Parallel()
+
+	state := &consul.State{Self: "node", Nodes: map[string]consul.Node{
+		"node": {Name: "node", Services: []consul.Service{{ID: "app", Meta: map[string]string{
+			listeners.HomepagePathKey:    "Apps/App",
+			listeners.PublishHomepageKey: "all",
+			listeners.HomepageWeightKey:  "first",
+		}}}},
+	}}
+
+	_, err := notify(t, Config{}, state, map[string]consul.Value{"app": []byte("href: /")})
+	if err == nil || !strings.Contains(err.Error(), `invalid homepage-weight value "first"`) {
+		t.Fatalf("Notify() error = %v, want invalid weight error", err)
+	}
+}
+
+func TestWriteSettings(t *testing.T) {
+	t.Parallel()
+
+	listener := &dashboard{Dashboard: Dashboard{Settings: &Settings{Common: "title: Home\ntheme: dark\n"}}}
+	var got bytes.Buffer
+	err := listener.writeSettings(&got, []string{"Media", "Home Automation"}, map[string]consul.Value{
+		"Media":  []byte("style: row\ncolumns: 4\n"),
+		"Unused": []byte("style: column"),
+	})
+	if err != nil {
+		t.Fatalf("writeSettings() error = %v", err)
+	}
+
+	want := "title: Home\n" +
+		"theme: dark\n" +
+		"\n" +
+		"layout:\n" +
+		"  Media:\n" +
+		"    style: row\n" +
+		"    columns: 4\n" +
+		"  Home Automation: {}\n"
+	if got.String() != want {
+		t.Errorf("writeSettings() = %q, want %q", got.String(), want)
+	}
+}
+
+func TestWriteBookmarks(t *testing.T) {
+	t.Parallel()
+
+	listener := &dashboard{Dashboard: Dashboard{Groups: []string{"Social"}}}
+	var got bytes.Buffer
+	err := listener.writeBookmarks(&got, map[string]consul.Value{
+		"Developer": []byte("- Github:\n    - abbr: GH\n      href: https://github.com/\n"),
+		"Social":    []byte("- Reddit:\n    - href: https://reddit.com/"),
+		"Empty":     []byte("  \n"),
+	})
+	if err != nil {
+		t.Fatalf("writeBookmarks() error = %v", err)
+	}
+
+	want := "- Social:\n" +
+		"    - Reddit:\n" +
+		"        - href: https://reddit.com/\n" +
+		"\n" +
+		"- Developer:\n" +
+		"    - Github:\n" +
+		"        - abbr: GH\n" +
+		"          href: https://github.com/\n"
+	if got.String() != want {
+		t.Errorf("writeBookmarks() = %q, want %q", got.String(), want)
+	}
+}
+
+func TestNotifyWritesSettingsAndBookmarks(t *testing.T) {
+	t.Parallel()
+
+	currentUser, err := user.Current()
+	if err != nil {
+		t.Fatalf("get current user: %v", err)
+	}
+	currentGroup, err := user.LookupGroupId(currentUser.Gid)
+	if err != nil {
+		t.Fatalf("get current group: %v", err)
+	}
+
+	dir := t.TempDir()
+	file := func(name string) listeners.File {
+		return listeners.File{
+			Path:  filepath.Join(dir, name),
+			Mode:  0o644,
+			User:  currentUser.Username,
+			Group: currentGroup.Name,
+		}
+	}
+
+	marker := filepath.Join(dir, "reloaded")
+	listener := newListener(t, Config{
+		KV: "homepage/services",
+		Dashboard: Dashboard{
+			Exec:      fmt.Sprintf("touch %q", marker),
+			Services:  file("services.yaml"),
+			Settings:  &Settings{File: file("settings.yaml"), KV: "homepage/layout"},
+			Bookmarks: &Bookmarks{File: file("bookmarks.yaml"), KV: "homepage/bookmarks"},
+		},
+	})
+	if got, want := strings.Join(listener.KV(), " "), "homepage/services homepage/layout homepage/bookmarks"; got != want {
+		t.Errorf("KV() = %q, want %q", got, want)
+	}
+
+	state := &consul.State{
+		Self: "node",
+		Nodes: map[string]consul.Node{
+			"node": {Name: "node", Services: []consul.Service{{ID: "app", Meta: map[string]string{
+				listeners.HomepagePathKey:    "Apps/App",
+				listeners.PublishHomepageKey: "all",
+			}}}},
+		},
+		KV: consul.Folder{"homepage": consul.Folder{
+			"services":  consul.Folder{"app": consul.Value("href: /")},
+			"layout":    consul.Folder{"Apps": consul.Value("style: row")},
+			"bookmarks": consul.Folder{"Links": consul.Value("- Docs:\n    - href: /docs")},
+		}},
+	}
+
+	if err := listener.Notify(context.Background(), state); err != nil {
+		t.Fatalf("first Notify() error = %v", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Fatalf("reload marker after changed configuration: %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"settings.yaml":  "layout:\n  Apps:\n    style: row\n",
+		"bookmarks.yaml": "- Links:\n    - Docs:\n        - href: /docs\n",
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", name, data, want)
+		}
 	}
+
+	if err := os.Remove(marker); err != nil {
+		t.Fatalf("remove reload marker: %v", err)
+	}
+	state.KV.Get("homepage/bookmarks").(consul.Folder)["Links"] = consul.Value("- Docs:\n    - href: /manual")
+	if err := listener.Notify(context.Background(), state); err != nil {
+		t.Fatalf("second Notify() error = %v", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Fatalf("reload marker after changed bookmarks: %v", err)
+	}
+}
+
+func TestNotifyRejectsInvalidSettingsAndBookmarks(t *testing.T) {
+	t.Parallel()
+
+	state := &consul.State{
+		Self: "node",
+		Nodes: map[string]consul.Node{
+			"node": {Name: "node", Services: []consul.Service{{ID: "app", Meta: map[string]string{
+				listeners.HomepagePathKey:    "Apps/App",
+				listeners.PublishHomepageKey: "all",
+			}}}},
+		},
+		KV: consul.Folder{"homepage": consul.Folder{
+			"services": consul.Folder{"app": consul.Value("href: /")},
+			"layout":   consul.Folder{"Apps": consul.Value("style: row\ncolumns: [4")},
+			"bookmarks": consul.Folder{
+				"Links":  consul.Value("- Docs:\n    - href: /docs"),
+				"Broken": consul.Value("href: /broken"),
+			},
+		}},
+	}
+
+	for _, tolerant := range []bool{false, true} {
+		settings, bookmarks := listenerstest.File(t, "settings.yaml"), listenerstest.File(t, "bookmarks.yaml")
+		listener := newListener(t, Config{
+			KV:       "homepage/services",
+			Tolerant: tolerant,
+			Dashboard: Dashboard{
+				Services:  listenerstest.File(t, "services.yaml"),
+				Settings:  &Settings{File: settings, KV: "homepage/layout"},
+				Bookmarks: &Bookmarks{File: bookmarks, KV: "homepage/bookmarks"},
+			},
+		})
+
+		err := listener.Notify(context.Background(), state)
+		if !tolerant {
+			if err == nil || !strings.Contains(err.Error(), "invalid YAML for homepage/layout/Apps") {
+				t.Fatalf("Notify() error = %v, want invalid layout error", err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("tolerant Notify() error = %v", err)
+		}
+
+		for file, want := range map[listeners.File]string{
+			settings:  "layout:\n  Apps: {}\n",
+			bookmarks: "- Links:\n    - Docs:\n        - href: /docs\n",
+		} {
+			data, err := os.ReadFile(file.Path)
+			if err != nil {
+				t.Fatalf("read %s: %v", file.Path, err)
+			}
+			if string(data) != want {
+				t.Errorf("%s = %q, want %q", filepath.Base(file.Path), data, want)
+			}
+		}
+	}
+}
+
+func TestWriteNestedGroups(t *testing.T) {
+	t.Parallel()
+
+	service := func(id, path string) consul.Service {
+		return consul.Service{ID: id, Meta: map[string]string{
+			listeners.HomepagePathKey:    path,
+			listeners.PublishHomepageKey: "all",
+		}}
+	}
+
+	state := &consul.State{Self: "node", Nodes: map[string]consul.Node{
+		"node": {Name: "node", Services: []consul.Service{
+			service("router", "Infra / Network / Router"),
+			service("switch", "Infra/Network/Core: Switch"),
+			service("grafana", "Infra/Grafana"),
+			service("nas", "Infra/Storage/NAS"),
+			service("wiki", "Docs/Wiki"),
+		}},
+	}}
+
+	definitions := make(map[string]consul.Value)
+	for _, id := range []string{"router", "switch", "grafana", "nas", "wiki"} {
+		definitions[id] = []byte("href: /" + id)
+	}
+
+	got, err := notify(t, Config{Dashboard: Dashboard{Groups: []string{"Infra/Storage"}}}, state, definitions)
+	if err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	want := "- Docs:\n" +
+		"    - Wiki:\n" +
+		"        href: /wiki\n" +
+		"\n" +
+		"- Infra:\n" +
+		"    - Storage:\n" +
+		"        - NAS:\n" +
+		"            href: /nas\n" +
+		"    - Grafana:\n" +
+		"        href: /grafana\n" +
+		"    - Network:\n" +
+		"        - 'Core: Switch':\n" +
+		"            href: /switch\n" +
+		"        - Router:\n" +
+		"            href: /router\n"
+	if got != want {
+		t.Errorf("Notify() = %q, want %q", got, want)
+	}
+
+	var document any
+	if err := yaml.Unmarshal([]byte(got), &document); err != nil {
+		t.Errorf("generated configuration is not valid YAML: %v", err)
+	}
+}
+
+func TestWriteRejectsEmptyPathSegment(t *testing.T) {
+	t.Parallel()
+
+	state := &consul.State{Self: "node", Nodes: map[string]consul.Node{
+		"node": {Name: "node", Services: []consul.Service{{ID: "app", Meta: map[string]string{
+			listeners.HomepagePathKey:    "Infra//App",
+			listeners.PublishHomepageKey: "all",
+		}}}},
+	}}
+
+	_, err := notify(t, Config{}, state, map[string]consul.Value{"app": []byte("href: /")})
+	if err == nil || !strings.Contains(err.Error(), `invalid homepage-path value "Infra//App"`) {
+		t.Fatalf("Notify() error = %v, want invalid path error", err)
+	}
+}
+
+func TestWriteTolerantSkipsBrokenServices(t *testing.T) {
+	t.Parallel()
+
+	state := &consul.State{Self: "node", Nodes: map[string]consul.Node{
+		"node": {Name: "node", Services: []consul.Service{
+			{ID: "app", Meta: map[string]string{
+				listeners.HomepagePathKey:    "Apps/App",
+				listeners.PublishHomepageKey: "all",
+			}},
+			{ID: "bad-template", Meta: map[string]string{
+				listeners.HomepagePathKey:    "Broken/Template",
+				listeners.PublishHomepageKey: "all",
+			}},
+			{ID: "bad-path", Meta: map[string]string{
+				listeners.HomepagePathKey:    "invalid",
+				listeners.PublishHomepageKey: "all",
+			}},
+			{ID: "bad-yaml", Meta: map[string]string{
+				listeners.HomepagePathKey:    "Broken/YAML",
+				listeners.PublishHomepageKey: "all",
+			}},
+		}},
+	}}
+	definitions := map[string]consul.Value{
+		"app":          []byte("href: /"),
+		"bad-template": []byte("[["),
+		"bad-path":     []byte("href: /"),
+		"bad-yaml":     []byte("- href: /"),
+	}
+
+	if _, err := notify(t, Config{}, state, definitions); err == nil {
+		t.Fatal("strict Notify() error = nil, want error")
+	}
+
+	got, err := notify(t, Config{Tolerant: true}, state, definitions)
+	if err != nil {
+		t.Fatalf("tolerant Notify() error = %v", err)
+	}
+
+	want := "- Apps:\n" +
+		"    - App:\n" +
+		"        href: /\n"
+	if got != want {
+		t.Errorf("Notify() = %q, want %q", got, want)
+	}
+}
+
+func TestNotifyWritesDashboards(t *testing.T) {
+	t.Parallel()
+
+	currentUser, err := user.Current()
+	if err != nil {
+		t.Fatalf("get current user: %v", err)
+	}
+	currentGroup, err := user.LookupGroupId(currentUser.Gid)
+	if err != nil {
+		t.Fatalf("get current group: %v", err)
+	}
+
+	dir := t.TempDir()
+	file := func(name string) listeners.File {
+		return listeners.File{
+			Path:  filepath.Join(dir, name),
+			Mode:  0o644,
+			User:  currentUser.Username,
+			Group: currentGroup.Name,
+		}
+	}
+
+	bookmarks := &Bookmarks{File: file("bookmarks.yaml"), KV: "homepage/bookmarks"}
+	listener := newListener(t, Config{
+		KV: "homepage/services",
+		Dashboard: Dashboard{
+			Services:  file("admin.yaml"),
+			Bookmarks: bookmarks,
+		},
+		Dashboards: []Dashboard{
+			{Name: "family", Services: file("family.yaml"), Bookmarks: bookmarks},
+			{Name: "guests", Key: "guest-dashboard", Services: file("guests.yaml")},
+		},
+	})
+	if got, want := strings.Join(listener.KV(), " "), "homepage/services homepage/bookmarks"; got != want {
+		t.Errorf("KV() = %q, want %q", got, want)
+	}
+
+	state := &consul.State{
+		Self: "node",
+		Nodes: map[string]consul.Node{
+			"node": {Name: "node", Services: []consul.Service{
+				{ID: "router", Meta: map[string]string{
+					listeners.HomepagePathKey:    "Infra/Router",
+					listeners.PublishHomepageKey: "all",
+				}},
+				{ID: "photos", Meta: map[string]string{
+					listeners.HomepagePathKey:                "Media/Photos",
+					listeners.PublishHomepageKey:             "all",
+					listeners.PublishHomepageKey + "-family": "all",
+					"guest-dashboard":                        "all",
+				}},
+			}},
+		},
+		KV: consul.Folder{"homepage": consul.Folder{
+			"services": consul.Folder{
+				"router": consul.Value("href: /router"),
+				"photos": consul.Value("href: /photos"),
+			},
+			"bookmarks": consul.Folder{},
+		}},
+	}
+
+	if err := listener.Notify(context.Background(), state); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	photos := "- Media:\n    - Photos:\n        href: /photos\n"
+	for name, want := range map[string]string{
+		"admin.yaml":  "- Infra:\n    - Router:\n        href: /router\n\n" + photos,
+		"family.yaml": photos,
+		"guests.yaml": photos,
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", name, data, want)
+		}
+	}
+}
+
+func TestNotifyWritesDashboardsAfterFailure(t *testing.T) {
+	t.Parallel()
+
+	admin, family := listenerstest.File(t, "admin.yaml"), listenerstest.File(t, "family.yaml")
+	listener := newListener(t, Config{
+		KV:        "homepage/services",
+		Dashboard: Dashboard{Services: admin, Exec: "false"},
+		Dashboards: []Dashboard{
+			{Name: "family", Services: family, Exec: "false"},
+			{Name: "guests", Key: "guest-dashboard", Services: listenerstest.File(t, "guests.yaml")},
+		},
+	})
+
+	state := &consul.State{
+		Self: "node",
+		Nodes: map[string]consul.Node{
+			"node": {Name: "node", Services: []consul.Service{
+				{ID: "photos", Meta: map[string]string{
+					listeners.HomepagePathKey:                "Media/Photos",
+					listeners.PublishHomepageKey:             "all",
+					listeners.PublishHomepageKey + "-family": "all",
+					"guest-dashboard":                        "all",
+				}},
+			}},
+		},
+		KV: consul.Folder{"homepage": consul.Folder{"services": consul.Folder{
+			"photos": consul.Value("href: /photos"),
+		}}},
+	}
+
+	err := listener.Notify(context.Background(), state)
+	if err == nil || !strings.Contains(err.Error(), "reload Homepage") || !strings.Contains(err.Error(), "dashboard family") {
+		t.Fatalf("Notify() error = %v, want reload errors of both dashboards", err)
+	}
+
+	guests := listener.dashboards[2].Services.Path
+	if data, err := os.ReadFile(guests); err != nil || string(data) != "- Media:\n    - Photos:\n        href: /photos\n" {
+		t.Errorf("guests.yaml = %q, %v; want the dashboard written after the failures", data, err)
+	}
+}
+
+func TestNewRejectsDashboardNames(t *testing.T) {
+	t.Parallel()
+
+	for _, dashboards := range [][]Dashboard{
+		{{Services: listeners.File{Path: "services.yaml"}}},
+		{{Name: "family"}, {Name: "family"}},
+	} {
+		if _, err := New(Config{Dashboards: dashboards}); err == nil || !strings.Contains(err.Error(), "is empty or not unique") {
+			t.Errorf("New(%+v) error = %v, want dashboard name error", dashboards, err)
+		}
+	}
+}
+
+func newListener(t *testing.T, cfg Config) *Listener {
+	t.Helper()
+	listener, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	return listener
+}
+
+// notify notifies a listener configured with cfg about state, with definitions as its KV
+// templates, and returns the services.yaml it wrote.
+func notify(t *testing.T, cfg Config, state *consul.State, definitions map[string]consul.Value) (string, error) {
+	t.Helper()
+
+	templates := make(consul.Folder, len(definitions))
+	for id, definition := range definitions {
+		templates[id] = definition
+	}
+
+	cfg.KV = "homepage"
+	cfg.Services = listenerstest.File(t, "services.yaml")
+	withKV := *state
+	withKV.KV = consul.Folder{"homepage": templates}
+	if err := newListener(t, cfg).Notify(context.Background(), &withKV); err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(cfg.Services.Path)
+	if err != nil {
+		t.Fatalf("read services.yaml: %v", err)
+	}
+
+	return string(data), nil
 }
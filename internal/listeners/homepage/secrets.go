@@ -0,0 +1,76 @@
+package homepage
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	secretName   = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+	envNameChars = regexp.MustCompile(`[.-]`)
+)
+
+// Secrets configures the secret template function, which resolves values on the rendering
+// node so that API keys used by Homepage widgets never have to be stored in Consul KV.
+type Secrets struct {
+	Dir string `yaml:"dir,omitempty" doc:"Directory with one file per secret, named after the secret; trailing newlines are removed"`
+	Env string `yaml:"env,omitempty" doc:"Prefix of environment variables with secrets, followed by the upper-cased secret name with dots and dashes replaced by underscores"`
+}
+
+// scalar returns the named secret as a double-quoted YAML scalar, so that the template can
+// insert it as a value whatever characters it contains.
+func (s Secrets) scalar(name string) (string, error) {
+	value, err := s.lookup(name)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := yaml.Marshal(&yaml.Node{Kind: yaml.ScalarNode, Style: yaml.DoubleQuotedStyle, Value: value})
+	if err != nil {
+		return "", errors.Wrapf(err, "quote secret %q", name)
+	}
+
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// lookup returns the value of the named secret. The secrets directory takes precedence
+// over environment variables.
+func (s Secrets) lookup(name string) (string, error) {
+	if !secretName.MatchString(name) {
+		return "", errors.Errorf("invalid secret name %q", name)
+	}
+
+	if s.Dir == "" && s.Env == "" {
+		return "", errors.Errorf("secret %q requested, but neither a secrets directory nor an environment prefix is configured", name)
+	}
+
+	var sources []string
+	if s.Dir != "" {
+		path := filepath.Join(s.Dir, name)
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			return strings.TrimRight(string(data), "\r\n"), nil
+		case !errors.Is(err, os.ErrNotExist):
+			return "", errors.Wrapf(err, "read secret %q", name)
+		}
+
+		sources = append(sources, path)
+	}
+
+	if s.Env != "" {
+		key := s.Env + strings.ToUpper(envNameChars.ReplaceAllString(name, "_"))
+		if value, ok := os.LookupEnv(key); ok {
+			return value, nil
+		}
+
+		sources = append(sources, "$"+key)
+	}
+
+	return "", errors.Errorf("secret %q not found in %s", name, strings.Join(sources, " or "))
+}
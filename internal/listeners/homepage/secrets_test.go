@@ -0,0 +1,67 @@
+package homepage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jfk9w/consul-publish/internal/consul"
+	"github.com/jfk9w/consul-publish/internal/listeners"
+)
+
+func TestWriteResolvesSecrets(t *testing.T) {
+	t.Setenv("HOMEPAGE_SECRET_SONARR_API_KEY", "from-env")
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "grafana_api_key"), []byte("from-file #1: @x\n"), 0o600); err != nil {
+		t.Fatalf("write secret: %v", err)
+	}
+
+	state := &consul.State{Self: "node", Nodes: map[string]consul.Node{
+		"node": {Name: "node", Services: []consul.Service{{ID: "app", Meta: map[string]string{
+			listeners.HomepagePathKey:    "Apps/App",
+			listeners.PublishHomepageKey: "all",
+		}}}},
+	}}
+
+	got, err := notify(t, Config{Secrets: Secrets{Dir: dir, Env: "HOMEPAGE_SECRET_"}}, state, map[string]consul.Value{"app": []byte(
+		"widget:\n  grafana: [[ secret \"grafana_api_key\" ]]\n  sonarr: [[ secret \"sonarr-api.key\" ]]",
+	)})
+	if err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	want := "- Apps:\n" +
+		"    - App:\n" +
+		"        widget:\n" +
+		"          grafana: \"from-file #1: @x\"\n" +
+		"          sonarr: \"from-env\"\n"
+	if got != want {
+		t.Errorf("Notify() = %q, want %q", got, want)
+	}
+}
+
+func TestSecretsLookupErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for name, tt := range map[string]struct {
+		secrets Secrets
+		secret  string
+		want    string
+	}{
+		"missing":        {secrets: Secrets{Dir: dir, Env: "TEST_SECRET_"}, secret: "api_key", want: `secret "api_key" not found in ` + filepath.Join(dir, "api_key") + " or $TEST_SECRET_API_KEY"},
+		"path traversal": {secrets: Secrets{Dir: dir}, secret: "../api_key", want: `invalid secret name "../api_key"`},
+		"not configured": {secret: "api_key", want: "neither a secrets directory nor an environment prefix is configured"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := tt.secrets.lookup(tt.secret)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("lookup() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package homepage
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jfk9w/consul-publish/internal/consul"
+	. "github.com/jfk9w/consul-publish/internal/listeners"
+)
+
+// Settings configures settings.yaml generation. The file gets a layout section with
+// an entry for every generated top-level group, in the same order as services.yaml.
+type Settings struct {
+	File   File   `yaml:"file" doc:"Homepage settings.yaml output file settings"`
+	KV     string `yaml:"kv,omitempty" doc:"Consul KV prefix with YAML layout settings such as style, columns and icon, keyed by group name"`
+	Common string `yaml:"common,omitempty" doc:"Static settings.yaml content written before the generated layout section"`
+}
+
+// Bookmarks configures bookmarks.yaml generation from Consul KV.
+type Bookmarks struct {
+	File File   `yaml:"file" doc:"Homepage bookmarks.yaml output file settings"`
+	KV   string `yaml:"kv" doc:"Consul KV prefix with YAML bookmark lists, keyed by bookmark group name"`
+}
+
+// writeSettings writes the static settings followed by a layout entry for each group.
+// Groups without a KV layout get an empty mapping so that Homepage keeps their order.
+func (d *dashboard) writeSettings(file io.Writer, groups []string, layouts map[string]consul.Value) error {
+	if common := strings.TrimSpace(d.Settings.Common); common != "" {
+		if _, err := fmt.Fprintf(file, "%s\n\n", common); err != nil {
+			return err
+		}
+	}
+
+	if len(groups) == 0 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintln(file, "layout:"); err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		layout := strings.TrimSpace(string(layouts[group]))
+		if layout == "" {
+			if _, err := fmt.Fprintf(file, "  %s: {}\n", yamlScalar(group)); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		layout = strings.ReplaceAll(layout, "\n", "\n    ")
+		if _, err := fmt.Fprintf(file, "  %s:\n    %s\n", yamlScalar(group), layout); err != nil {
+			return errors.Wrapf(err, "write layout for %s", group)
+		}
+	}
+
+	return nil
+}
+
+// writeBookmarks writes bookmark groups from KV, ordered like service groups.
+func (d *dashboard) writeBookmarks(file io.Writer, bookmarks map[string]consul.Value) error {
+	position := d.groupPosition()
+	maps.DeleteFunc(bookmarks, func(_ string, value consul.Value) bool {
+		return strings.TrimSpace(string(value)) == ""
+	})
+
+	groups := slices.SortedFunc(maps.Keys(bookmarks), func(a, b string) int {
+		return cmp.Or(cmp.Compare(position(a), position(b)), cmp.Compare(a, b))
+	})
+
+	for i, group := range groups {
+		if i > 0 {
+			if _, err := fmt.Fprintln(file); err != nil {
+				return err
+			}
+		}
+
+		content := strings.TrimSpace(string(bookmarks[group]))
+		content = strings.ReplaceAll(content, "\n", "\n    ")
+		if _, err := fmt.Fprintf(file, "- %s:\n    %s\n", yamlScalar(group), content); err != nil {
+			return errors.Wrapf(err, "write bookmarks for %s", group)
+		}
+	}
+
+	return nil
+}
+
+// checkValues verifies that the KV values under prefix are YAML nodes of the given kind.
+// Invalid values are passed to errs under their KV key and left out when it tolerates them.
+func checkValues(prefix string, values map[string]consul.Value, kind yaml.Kind, errs *ServiceErrors) error {
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		key := prefix + "/" + name
+		err := checkFragment(key, strings.TrimSpace(string(values[name])), kind)
+		if err == nil {
+			continue
+		}
+
+		if err := errs.Handle(key, err); err != nil {
+			return err
+		}
+
+		delete(values, name)
+	}
+
+	return nil
+}
+
+// values returns the direct values under the KV prefix, or nil when prefix is empty.
+func values(state *consul.State, prefix string) (map[string]consul.Value, error) {
+	if prefix == "" {
+		return nil, nil
+	}
+
+	folder, ok := state.KV.Get(prefix).(consul.Folder)
+	if !ok {
+		return nil, errors.Errorf("%s is not a folder", prefix)
+	}
+
+	return maps.Collect(folder.Values()), nil
+}
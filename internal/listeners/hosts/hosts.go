@@ -1,10 +1,14 @@
 package hosts
 
 import (
+	"errors"
+	"io/fs"
 	"iter"
 	"maps"
+	"os"
 	"slices"
 	"sort"
+	"strings"
 
 	"github.com/jfk9w/consul-publish/internal/lib"
 )
@@ -57,3 +61,42 @@ func (h hosts) iter() iter.Seq2[string, []string] {
 		}
 	}
 }
+
+// hostnames returns the names of h. Names are compared without addresses, so that moving a
+// name to another address does not count as a removal.
+func (h hosts) hostnames() lib.Set[string] {
+	names := make(lib.Set[string])
+	for _, entry := range h {
+		if entry.canonical != "" {
+			names.Add(entry.canonical)
+		}
+
+		for name := range entry.aliases {
+			names.Add(name)
+		}
+	}
+
+	return names
+}
+
+// readNames returns the host names of the hosts file at path. A missing file has no names.
+func readNames(path string) (lib.Set[string], error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return make(lib.Set[string]), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	names := make(lib.Set[string])
+	for line := range strings.Lines(string(data)) {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		names.Add(fields[1:]...)
+	}
+
+	return names, nil
+}
@@ -1,10 +1,16 @@
 package hosts
 
 import (
+	"context"
+	"maps"
+	"os"
 	"slices"
+	"strings"
 	"testing"
 
 	"github.com/jfk9w/consul-publish/internal/consul"
+	"github.com/jfk9w/consul-publish/internal/listeners"
+	"github.com/jfk9w/consul-publish/internal/listeners/listenerstest"
 	"github.com/stretchr/testify/require"
 )
 
@@ -96,3 +102,61 @@ func collectHosts(hosts hosts) []hostEntry {
 		}
 	})
 }
+
+func TestListenerSafeguardBlocksMassDeletion(t *testing.T) {
+	file := listenerstest.File(t, "hosts")
+	path := file.Path
+	listener := New(Config{
+		File: file,
+		Safeguard: listeners.Safeguard{
+			MaxDeleteRatio: 0.5,
+			Confirm:        "consul-publish/confirm",
+		},
+	})
+
+	require.Equal(t, []string{"consul-publish/confirm"}, listener.KV())
+
+	full := &consul.State{
+		Self: "mars",
+		Nodes: map[string]consul.Node{
+			"mars":    {ID: "mars", Name: "mars", Address: "10.0.0.1"},
+			"venus":   {ID: "venus", Name: "venus", Address: "10.0.0.2"},
+			"jupiter": {ID: "jupiter", Name: "jupiter", Address: "10.0.0.3"},
+		},
+		KV: consul.Folder{},
+	}
+
+	require.NoError(t, listener.Notify(context.Background(), full))
+
+	// Moving names to another address removes no names, so it is not blocked.
+	renumbered := &consul.State{Self: "mars", Nodes: maps.Clone(full.Nodes), KV: consul.Folder{}}
+	for _, name := range []string{"venus", "jupiter"} {
+		node := renumbered.Nodes[name]
+		node.Address = strings.Replace(node.Address, "10.0.0.", "10.0.1.", 1)
+		renumbered.Nodes[name] = node
+	}
+
+	require.NoError(t, listener.Notify(context.Background(), renumbered))
+	written, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "10.0.1.2 venus\n10.0.1.3 jupiter\n127.0.0.1 mars\n", string(written))
+
+	full = renumbered
+
+	empty := &consul.State{
+		Self:  "mars",
+		Nodes: map[string]consul.Node{"mars": full.Nodes["mars"]},
+		KV:    consul.Folder{},
+	}
+
+	require.NoError(t, listener.Notify(context.Background(), empty))
+	current, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, string(written), string(current), "mass deletion must be blocked")
+
+	empty.KV = consul.Folder{"consul-publish": consul.Folder{"confirm": consul.Folder{"hosts": consul.Value("1")}}}
+	require.NoError(t, listener.Notify(context.Background(), empty))
+	current, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1 mars\n", string(current))
+}
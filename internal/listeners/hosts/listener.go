@@ -18,33 +18,59 @@ import (
 
 // Config holds the file output settings for the hosts listener.
 type Config struct {
-	File File `yaml:",inline"`
+	File      File      `yaml:",inline"`
+	Safeguard Safeguard `yaml:"safeguard,omitempty" doc:"Protection against changes that remove most of the host entries at once"`
 }
 
 // Listener writes /etc/hosts (or a custom path) based on the Consul node and service inventory.
 type Listener struct {
-	cfg Config
+	cfg   Config
+	guard *Guard
 }
 
 // New creates a Listener with the given configuration.
 func New(cfg Config) Listener {
 	return Listener{
-		cfg: cfg,
+		cfg:   cfg,
+		guard: NewGuard("hosts", cfg.Safeguard),
 	}
 }
 
+// Name returns the listener name used in logs and metrics.
+func (l Listener) Name() string {
+	return "hosts"
+}
+
 func (l Listener) KV() []string {
-	return nil
+	return l.guard.KV()
 }
 
 // Notify regenerates the hosts file from the current Consul state.
 // Each node is mapped to its IP address; the local node is mapped to 127.0.0.1.
 // Domain names are added as aliases when they occur on exactly one node. The local
 // node gets all of its published domain names regardless of uniqueness.
+// Changes that remove too many host names of the current file are blocked by the safeguard.
 func (l Listener) Notify(ctx context.Context, state *consul.State) error {
 	hosts := buildHosts(state)
 
-	_, err := l.cfg.File.Write(func(file io.Writer) error {
+	applied, err := readNames(l.cfg.File.Path)
+	if err != nil {
+		return errors.Wrap(err, "read current host names")
+	}
+
+	names := hosts.hostnames()
+	removed := 0
+	for name := range applied {
+		if !names[name] {
+			removed++
+		}
+	}
+
+	if !l.guard.Begin(state).Allow("host name", len(applied), removed) {
+		return nil
+	}
+
+	_, err = l.cfg.File.Write(func(file io.Writer) error {
 		for address, names := range hosts.iter() {
 			if _, err := fmt.Fprintln(file, address, strings.Join(names, " ")); err != nil {
 				return errors.Wrap(err, "write to temp file")
@@ -0,0 +1,32 @@
+// Package listenerstest provides helpers for testing the listeners.
+package listenerstest
+
+import (
+	"os/user"
+	"path/filepath"
+	"testing"
+
+	"github.com/jfk9w/consul-publish/internal/listeners"
+)
+
+// File returns a listeners.File named name in a temporary directory,
+// owned by the current user so that it can be written without root privileges.
+func File(t testing.TB, name string) listeners.File {
+	t.Helper()
+
+	currentUser, err := user.Current()
+	if err != nil {
+		t.Fatalf("get current user: %v", err)
+	}
+	currentGroup, err := user.LookupGroupId(currentUser.Gid)
+	if err != nil {
+		t.Fatalf("get current group: %v", err)
+	}
+
+	return listeners.File{
+		Path:  filepath.Join(t.TempDir(), name),
+		Mode:  0o644,
+		User:  currentUser.Username,
+		Group: currentGroup.Name,
+	}
+}
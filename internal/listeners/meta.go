@@ -1,6 +1,8 @@
 package listeners
 
 import (
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/jfk9w/consul-publish/internal/consul"
@@ -9,11 +11,22 @@ import (
 
 // Service metadata keys used by the listeners.
 const (
-	DomainNameKey      = "domain-name"      // space-separated DNS names; http:// / https:// prefixes are stripped
-	HomepagePathKey    = "homepage-path"    // Homepage placement in the form group/service-name
-	PublishHTTPKey     = "publish-http"     // group selector — service is published only when the local node is a member
-	PublishHomepageKey = "publish-homepage" // group selector — service is added to Homepage only when the local node is a member
-	PublishPathKey     = "publish-path"     // URL path prefix for Caddy reverse-proxy entries
+	DomainNameKey          = "domain-name"           // space-separated DNS names; http:// / https:// prefixes are stripped
+	HomepagePathKey        = "homepage-path"         // Homepage placement in the form group[/subgroup...]/service-name
+	HomepageIconKey        = "homepage-icon"         // Homepage icon used when the service has no KV template
+	HomepageDescriptionKey = "homepage-description"  // Homepage description used when the service has no KV template
+	HomepageHrefKey        = "homepage-href"         // Homepage link used when the service has no KV template; defaults to the first domain-name
+	HomepageWeightKey      = "homepage-weight"       // integer order of the Homepage entry and its group; lower comes first, ties are sorted by name
+	MikrotikAddressKey     = "mikrotik-address"      // address tag or literal address of the MikroTik DNS records instead of the node address
+	MikrotikAddressListKey = "mikrotik-address-list" // space-separated MikroTik firewall address lists that get the node address
+	MikrotikDisabledKey    = "mikrotik-disabled"     // "true" excludes the domain names from MikroTik DNS records
+	MikrotikTTLKey         = "mikrotik-ttl"          // TTL of the MikroTik DNS records as a Go duration, e.g. 1h
+	MetricsPathKey         = "metrics-path"          // HTTP path of the Prometheus metrics; the service is discovered as a scrape target
+	MetricsPortKey         = "metrics-port"          // port of the Prometheus metrics when it differs from the service port
+	PublishHTTPKey         = "publish-http"          // group selector — service is published only when the local node is a member
+	PublishHomepageKey     = "publish-homepage"      // group selector — service is added to Homepage only when the local node is a member
+	PublishPathKey         = "publish-path"          // URL path prefix for Caddy reverse-proxy entries
+	PublishWANKey          = "publish-wan"           // space-separated MikroTik port forwards in the form protocol:external->internal
 )
 
 // GetDomainName returns the raw value of the domain-name metadata key.
@@ -41,6 +54,28 @@ func GetDomainNames(meta map[string]string) []string {
 	return names
 }
 
+// IsMikrotikDisabled reports whether the mikrotik-disabled metadata key is set to a true value.
+func IsMikrotikDisabled(meta map[string]string) bool {
+	disabled, _ := strconv.ParseBool(meta[MikrotikDisabledKey])
+	return disabled
+}
+
+// GetHostNames returns the host names from the domain-name metadata key
+// with schemes and ports stripped.
+func GetHostNames(meta map[string]string) []string {
+	var names []string
+	for _, domain := range GetDomainNames(meta) {
+		parsed, err := url.Parse("//" + domain)
+		if err != nil || parsed.Hostname() == "" {
+			continue
+		}
+
+		names = append(names, parsed.Hostname())
+	}
+
+	return names
+}
+
 // GetHTTPDomainNames returns domain names for HTTP-published services.
 // It returns nil when the local node is not a member of the publish-http group.
 func GetHTTPDomainNames(state *consul.State, meta map[string]string) []string {
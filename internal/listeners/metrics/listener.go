@@ -3,7 +3,7 @@ package metrics
 
 import (
 	"context"
-	"errors"
+	"io"
 	"log/slog"
 	"net"
 	"net/http"
@@ -11,10 +11,12 @@ import (
 	"sync"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/jfk9w/consul-publish/internal/consul"
+	"github.com/jfk9w/consul-publish/internal/listeners"
 )
 
 const shutdownTimeout = 5 * time.Second
@@ -23,6 +25,7 @@ const shutdownTimeout = 5 * time.Second
 type Config struct {
 	Listen string `yaml:"listen" default:"0.0.0.0:9634" doc:"Prometheus metrics listen address"`
 	Path   string `yaml:"path"   default:"/metrics"       doc:"Prometheus metrics HTTP path"`
+	SD     SD     `yaml:"sd,omitempty" doc:"Prometheus service discovery of Consul services"`
 }
 
 // Listener consumes Consul snapshots and implements prometheus.Collector.
@@ -33,6 +36,7 @@ type Listener struct {
 	groups     []string
 	ready      bool
 	lastUpdate time.Time
+	targets    []TargetGroup
 
 	groupDesc      *prometheus.Desc
 	readyDesc      *prometheus.Desc
@@ -41,6 +45,7 @@ type Listener struct {
 }
 
 // New creates an isolated Prometheus exporter and registry.
+// The registry also exposes the collectors shared by all listeners and the watcher.
 func New(cfg Config) *Listener {
 	l := &Listener{
 		cfg: cfg,
@@ -62,13 +67,37 @@ func New(cfg Config) *Listener {
 		registry: prometheus.NewRegistry(),
 	}
 	l.registry.MustRegister(l)
+	l.registry.MustRegister(listeners.Collectors()...)
+	l.registry.MustRegister(consul.Collectors()...)
 	return l
 }
 
+// MustRegister adds collectors maintained outside of the listeners, such as API clients, to the registry.
+func (l *Listener) MustRegister(collectors ...prometheus.Collector) {
+	l.registry.MustRegister(collectors...)
+}
+
+// Name returns the listener name used in logs and metrics.
+func (l *Listener) Name() string { return "metrics" }
+
 func (l *Listener) KV() []string { return nil }
 
-// Notify atomically replaces the exported local-node snapshot.
+// Notify atomically replaces the exported local-node snapshot and the discovered targets.
+// The file_sd file is rewritten when its content changes.
 func (l *Listener) Notify(_ context.Context, state *consul.State) error {
+	if l.cfg.SD.Enabled {
+		targets := l.cfg.SD.targets(state)
+		if l.cfg.SD.File.Path != "" {
+			if _, err := l.cfg.SD.File.Write(func(file io.Writer) error { return writeTargets(file, targets) }); err != nil {
+				return errors.Wrap(err, "write file_sd targets")
+			}
+		}
+
+		l.mu.Lock()
+		l.targets = targets
+		l.mu.Unlock()
+	}
+
 	node, ok := state.Nodes[state.Self]
 	if !ok {
 		return nil
@@ -118,10 +147,15 @@ func (l *Listener) Collect(ch chan<- prometheus.Metric) {
 	ch <- prometheus.MustNewConstMetric(l.lastUpdateDesc, prometheus.GaugeValue, lastUpdateValue)
 }
 
-// Handler returns an HTTP handler that serves metrics only at the configured path.
+// Handler returns an HTTP handler that serves metrics only at the configured path
+// and, when service discovery is enabled, http_sd targets at the SD path.
 func (l *Listener) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.Handle(l.cfg.Path, promhttp.HandlerFor(l.registry, promhttp.HandlerOpts{}))
+	if l.cfg.SD.Enabled {
+		mux.HandleFunc(l.cfg.SD.Path, l.serveTargets)
+	}
+
 	return mux
 }
 
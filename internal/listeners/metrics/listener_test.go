@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -16,6 +17,7 @@ import (
 
 	"github.com/jfk9w/consul-publish/internal/consul"
 	"github.com/jfk9w/consul-publish/internal/lib"
+	"github.com/jfk9w/consul-publish/internal/listeners"
 )
 
 func TestListenerExportsOnlyLocalGroups(t *testing.T) {
@@ -178,3 +180,43 @@ func scrape(t *testing.T, listener *Listener, path string) string {
 	require.NoError(t, err)
 	return string(body)
 }
+
+func TestListenerExportsTemplateErrors(t *testing.T) {
+	errs := listeners.NewServiceErrors("metrics-test", true)
+	require.NoError(t, errs.Handle("broken", assert.AnError))
+	errs.Report()
+
+	l := New(Config{Path: "/metrics"})
+	assert.Contains(t, scrape(t, l, "/metrics"), `consul_publish_template_errors{listener="metrics-test",service="broken"} 1`)
+
+	listeners.NewServiceErrors("metrics-test", true).Report()
+	assert.NotContains(t, scrape(t, l, "/metrics"), `listener="metrics-test"`)
+}
+
+func TestListenerExportsListenerMetrics(t *testing.T) {
+	l := New(Config{Path: "/metrics"})
+	runs, failures := `consul_publish_exec_runs_total{listener="exec-test"}`, `consul_publish_exec_failures_total{listener="exec-test"}`
+	before := scrape(t, l, "/metrics")
+
+	require.NoError(t, listeners.Exec(t.Context(), "exec-test", "true"))
+	require.Error(t, listeners.Exec(t.Context(), "exec-test", "false"))
+
+	body := scrape(t, l, "/metrics")
+	assert.Equal(t, 2.0, value(t, body, runs)-value(t, before, runs))
+	assert.Equal(t, 1.0, value(t, body, failures)-value(t, before, failures))
+	assert.NotContains(t, body, `command=`)
+}
+
+// value returns the value of series in the scraped body, or 0 when the series is missing.
+func value(t *testing.T, body, series string) float64 {
+	t.Helper()
+	for line := range strings.Lines(body) {
+		if text, ok := strings.CutPrefix(strings.TrimSpace(line), series+" "); ok {
+			value, err := strconv.ParseFloat(text, 64)
+			require.NoError(t, err)
+			return value
+		}
+	}
+
+	return 0
+}
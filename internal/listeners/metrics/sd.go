@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"cmp"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jfk9w/consul-publish/internal/consul"
+	"github.com/jfk9w/consul-publish/internal/listeners"
+)
+
+// SD holds the Prometheus service discovery settings.
+type SD struct {
+	Enabled bool           `yaml:"enabled,omitempty" doc:"Serve Prometheus http_sd targets for Consul services with metrics-path or metrics-port metadata"`
+	Path    string         `yaml:"path,omitempty" default:"/sd" doc:"HTTP path of the http_sd endpoint"`
+	Groups  []string       `yaml:"groups,omitempty" doc:"Consul node groups whose services are discovered; all nodes when empty"`
+	File    listeners.File `yaml:"file,omitempty" doc:"Prometheus file_sd target file, written in JSON format when the path is set"`
+}
+
+// TargetGroup is a Prometheus http_sd and file_sd target group.
+type TargetGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels"`
+}
+
+// Labels of the discovered targets.
+const (
+	nodeLabel        = "node"
+	serviceLabel     = "service"
+	groupLabel       = "group"
+	metricsPathLabel = "__metrics_path__"
+)
+
+// targets returns a target group for every service instance with metrics-path or metrics-port
+// metadata on the nodes of the configured groups, ordered by node name and service ID.
+// The group label holds the matching node groups sorted and joined with commas.
+func (c SD) targets(state *consul.State) []TargetGroup {
+	groups := make([]TargetGroup, 0)
+	for _, node := range sortedNodes(state) {
+		nodeGroups, ok := c.nodeGroups(state, node)
+		if !ok {
+			continue
+		}
+
+		services := slices.SortedFunc(slices.Values(node.Services), func(a, b consul.Service) int {
+			return cmp.Compare(a.ID, b.ID)
+		})
+
+		for _, service := range services {
+			path, hasPath := service.Meta[listeners.MetricsPathKey]
+			port, hasPort := service.Meta[listeners.MetricsPortKey]
+			if !hasPath && !hasPort {
+				continue
+			}
+
+			if !hasPort {
+				port = strconv.Itoa(service.Port)
+			} else if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+				slog.Warn("invalid metrics port", "node", node.Name, "service", service.ID, "port", port)
+				continue
+			}
+
+			address := cmp.Or(service.Address, node.Address)
+			labels := map[string]string{
+				nodeLabel:    node.Name,
+				serviceLabel: service.Name,
+				groupLabel:   strings.Join(nodeGroups, ","),
+			}
+
+			if hasPath {
+				labels[metricsPathLabel] = path
+			}
+
+			groups = append(groups, TargetGroup{
+				Targets: []string{net.JoinHostPort(address, port)},
+				Labels:  labels,
+			})
+		}
+	}
+
+	return groups
+}
+
+// nodeGroups returns the sorted groups of node matching the configured groups.
+// It returns false when node is not a member of any of them.
+func (c SD) nodeGroups(state *consul.State, node consul.Node) ([]string, bool) {
+	if len(c.Groups) == 0 {
+		return node.Groups.Sort(), true
+	}
+
+	var groups []string
+	for _, group := range c.Groups {
+		if state.Group(group)[node.Name] {
+			groups = append(groups, group)
+		}
+	}
+
+	slices.Sort(groups)
+	return slices.Compact(groups), groups != nil
+}
+
+func sortedNodes(state *consul.State) []consul.Node {
+	nodes := make([]consul.Node, 0, len(state.Nodes))
+	for _, node := range state.Nodes {
+		nodes = append(nodes, node)
+	}
+
+	slices.SortFunc(nodes, func(a, b consul.Node) int { return cmp.Compare(a.Name, b.Name) })
+	return nodes
+}
+
+func writeTargets(w io.Writer, targets []TargetGroup) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(targets)
+}
+
+func (l *Listener) serveTargets(w http.ResponseWriter, _ *http.Request) {
+	l.mu.RLock()
+	targets := l.targets
+	l.mu.RUnlock()
+
+	if targets == nil {
+		targets = []TargetGroup{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := writeTargets(w, targets); err != nil {
+		slog.Warn("failed to write http_sd targets", "error", err)
+	}
+}
@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jfk9w/consul-publish/internal/consul"
+	"github.com/jfk9w/consul-publish/internal/lib"
+	"github.com/jfk9w/consul-publish/internal/listeners"
+	"github.com/jfk9w/consul-publish/internal/listeners/listenerstest"
+)
+
+func TestSDTargets(t *testing.T) {
+	l := New(Config{Path: "/metrics", SD: SD{Enabled: true, Path: "/sd"}})
+	require.NoError(t, l.Notify(t.Context(), sdState()))
+
+	var targets []TargetGroup
+	require.NoError(t, json.Unmarshal([]byte(scrape(t, l, "/sd")), &targets))
+	assert.Equal(t, []TargetGroup{
+		{
+			Targets: []string{"10.0.0.1:9100"},
+			Labels:  map[string]string{"node": "home", "service": "node-exporter", "group": "home,site", "__metrics_path__": "/metrics"},
+		},
+		{
+			Targets: []string{"10.0.0.5:8080"},
+			Labels:  map[string]string{"node": "home", "service": "web", "group": "home,site", "__metrics_path__": "/stats"},
+		},
+		{
+			Targets: []string{"10.0.0.2:9100"},
+			Labels:  map[string]string{"node": "vps", "service": "node-exporter", "group": "cloud"},
+		},
+	}, targets)
+}
+
+func TestSDFiltersGroups(t *testing.T) {
+	sd := SD{Enabled: true, Groups: []string{"site", "home", "missing"}}
+	targets := sd.targets(sdState())
+	require.Len(t, targets, 2)
+	for _, target := range targets {
+		assert.Equal(t, "home", target.Labels["node"])
+		assert.Equal(t, "home,site", target.Labels["group"])
+	}
+}
+
+func TestSDSkipsInvalidPort(t *testing.T) {
+	state := sdState()
+	node := state.Nodes["vps"]
+	node.Services[0].Meta[listeners.MetricsPortKey] = "http"
+
+	targets := SD{Enabled: true}.targets(state)
+	assert.Len(t, targets, 2)
+}
+
+func TestSDEndpointDisabled(t *testing.T) {
+	l := New(Config{Path: "/metrics"})
+	require.NoError(t, l.Notify(t.Context(), sdState()))
+
+	recorder := httptest.NewRecorder()
+	l.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/sd", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestSDEndpointEmptyBeforeNotify(t *testing.T) {
+	l := New(Config{Path: "/metrics", SD: SD{Enabled: true, Path: "/sd"}})
+	assert.JSONEq(t, `[]`, scrape(t, l, "/sd"))
+}
+
+func TestSDWritesFile(t *testing.T) {
+	file := listenerstest.File(t, "targets.json")
+	l := New(Config{Path: "/metrics", SD: SD{Enabled: true, Path: "/sd", File: file}})
+	require.NoError(t, l.Notify(t.Context(), sdState()))
+
+	data, err := os.ReadFile(file.Path)
+	require.NoError(t, err)
+	assert.JSONEq(t, scrape(t, l, "/sd"), string(data))
+}
+
+func sdState() *consul.State {
+	return &consul.State{
+		Self: "home",
+		Nodes: map[string]consul.Node{
+			"home": {
+				Name:    "home",
+				Address: "10.0.0.1",
+				Groups:  lib.SetOf("site", "home"),
+				Services: []consul.Service{
+					{
+						ID:      "web",
+						Name:    "web",
+						Address: "10.0.0.5",
+						Port:    443,
+						Meta:    map[string]string{listeners.MetricsPathKey: "/stats", listeners.MetricsPortKey: "8080"},
+					},
+					{
+						ID:   "node-exporter",
+						Name: "node-exporter",
+						Port: 9100,
+						Meta: map[string]string{listeners.MetricsPathKey: "/metrics"},
+					},
+					{
+						ID:   "ssh",
+						Name: "ssh",
+						Port: 22,
+					},
+				},
+			},
+			"vps": {
+				Name:    "vps",
+				Address: "10.0.0.2",
+				Groups:  lib.SetOf("cloud"),
+				Services: []consul.Service{
+					{
+						ID:   "node-exporter",
+						Name: "node-exporter",
+						Port: 8000,
+						Meta: map[string]string{listeners.MetricsPortKey: "9100"},
+					},
+				},
+			},
+		},
+	}
+}
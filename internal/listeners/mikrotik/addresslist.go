@@ -0,0 +1,84 @@
+package mikrotik
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jfk9w/consul-publish/internal/consul"
+	"github.com/jfk9w/consul-publish/internal/listeners"
+	mtkapi "github.com/jfk9w/consul-publish/internal/mikrotik"
+)
+
+// AddressLists configures synchronisation of firewall address lists with Consul node groups
+// and the mikrotik-address-list service metadata key.
+type AddressLists struct {
+	Enabled bool     `yaml:"enabled,omitempty" doc:"Synchronise firewall address lists with Consul node groups and service metadata"`
+	Groups  []string `yaml:"groups,omitempty" doc:"Consul node groups whose member addresses are published to address lists of the same name"`
+	Prefix  string   `yaml:"prefix,omitempty" doc:"Prefix added to the names of the managed address lists"`
+}
+
+// desiredEntries returns the desired address list entries keyed by entryKey.
+// Every node gets an entry in the lists of the configured groups it belongs to
+// and in the lists named by the mikrotik-address-list metadata of its services.
+func (l *Listener) desiredEntries(state *consul.State) map[string]mtkapi.AddressListEntry {
+	desired := make(map[string]mtkapi.AddressListEntry)
+	add := func(node consul.Node, list string) {
+		if node.Address == "" {
+			return
+		}
+
+		entry := mtkapi.AddressListEntry{List: l.cfg.AddressLists.Prefix + list, Address: node.Address}
+		desired[entryKey(entry)] = entry
+	}
+
+	for _, node := range l.nodes(state) {
+		for _, group := range l.cfg.AddressLists.Groups {
+			if state.Group(group)[node.Name] {
+				add(node, group)
+			}
+		}
+
+		for _, service := range node.Services {
+			for _, list := range strings.Fields(service.Meta[listeners.MikrotikAddressListKey]) {
+				add(node, list)
+			}
+		}
+	}
+
+	return desired
+}
+
+// addressListEntries returns the reconciler of the address list entries tagged with the configured comment.
+func (l *Listener) addressListEntries(check *listeners.Check) reconciler[mtkapi.AddressListEntry] {
+	return reconciler[mtkapi.AddressListEntry]{
+		kind: "address list entry",
+		key:  entryKey,
+		name: func(entry mtkapi.AddressListEntry) string {
+			return entry.List + "/" + entry.Address
+		},
+		equal: func(_, _ mtkapi.AddressListEntry) bool {
+			// The key covers all managed fields.
+			return true
+		},
+		id: func(entry mtkapi.AddressListEntry) string { return entry.ID },
+		find: func(ctx context.Context) ([]mtkapi.AddressListEntry, error) {
+			return l.client.FindAddressListEntries(ctx, mtkapi.AddressListEntry{Comment: l.cfg.Comment})
+		},
+		create: func(ctx context.Context, entry mtkapi.AddressListEntry) error {
+			entry.Comment = l.cfg.Comment
+			_, err := l.client.CreateAddressListEntry(ctx, entry)
+			return err
+		},
+		update: func(ctx context.Context, id string, entry mtkapi.AddressListEntry) error {
+			entry.ID, entry.Comment = id, l.cfg.Comment
+			_, err := l.client.UpdateAddressListEntry(ctx, entry)
+			return err
+		},
+		allow:  check.Allow,
+		delete: l.client.DeleteAddressListEntry,
+	}
+}
+
+func entryKey(entry mtkapi.AddressListEntry) string {
+	return entry.List + "\x00" + entry.Address
+}
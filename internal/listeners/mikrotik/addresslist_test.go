@@ -0,0 +1,64 @@
+package mikrotik_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+
+	"github.com/jfk9w/consul-publish/internal/consul"
+	"github.com/jfk9w/consul-publish/internal/lib"
+	"github.com/jfk9w/consul-publish/internal/listeners/mikrotik"
+	mtkapi "github.com/jfk9w/consul-publish/internal/mikrotik"
+)
+
+func entryOf(id, list, address string) mtkapi.AddressListEntry {
+	return mtkapi.AddressListEntry{ID: id, List: list, Address: address, Comment: testComment}
+}
+
+func TestListener_Notify_AddressLists(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	dns := mikrotik.NewMockDNSClient(ctrl)
+	lists := mikrotik.NewMockAddressListClient(ctrl)
+	l := mikrotik.NewListenerWithClient(mikrotik.ListenerConfig{
+		TTL:     testTTL,
+		Comment: testComment,
+		AddressLists: mikrotik.AddressLists{
+			Enabled: true,
+			Groups:  []string{"servers", "iot"},
+			Prefix:  "consul-",
+		},
+	}, mockClient{dns, lists, mikrotik.NewMockNATClient(ctrl)})
+
+	state := &consul.State{
+		Self: "node1",
+		Nodes: map[string]consul.Node{
+			"node1": {
+				Name:    "node1",
+				Address: "10.0.0.1",
+				Groups:  lib.SetOf("servers"),
+				Services: []consul.Service{{
+					ID:   "web",
+					Meta: map[string]string{"mikrotik-address-list": "web public"},
+				}},
+			},
+			"node2": {Name: "node2", Address: "10.0.0.2", Groups: lib.SetOf("iot")},
+		},
+	}
+
+	dns.EXPECT().FindDNSRecords(gomock.Any(), mtkapi.DNSRecord{Comment: testComment}).Return(nil, nil)
+	lists.EXPECT().FindAddressListEntries(gomock.Any(), mtkapi.AddressListEntry{Comment: testComment}).
+		Return([]mtkapi.AddressListEntry{
+			entryOf("*1", "consul-servers", "10.0.0.1"),
+			entryOf("*2", "consul-web", "10.0.0.1"),
+			entryOf("*3", "consul-web", "10.0.0.1"), // duplicate
+			entryOf("*4", "consul-iot", "10.0.0.1"), // node1 is not in iot
+		}, nil)
+	lists.EXPECT().DeleteAddressListEntry(gomock.Any(), "*3").Return(nil)
+	lists.EXPECT().DeleteAddressListEntry(gomock.Any(), "*4").Return(nil)
+	lists.EXPECT().CreateAddressListEntry(gomock.Any(), entryOf("", "consul-public", "10.0.0.1")).
+		Return(entryOf("*5", "consul-public", "10.0.0.1"), nil)
+
+	require.NoError(t, l.Notify(context.Background(), state))
+}
@@ -1,14 +1,62 @@
 package mikrotik
 
-import mtkapi "github.com/jfk9w/consul-publish/internal/mikrotik"
+import (
+	"context"
 
-//go:generate mockgen -destination mocks_test.go -package mikrotik -typed . DNSClient
+	capi "github.com/hashicorp/consul/api"
 
-// DNSClient is the interface the listener uses to manage MikroTik DNS records.
+	mtkapi "github.com/jfk9w/consul-publish/internal/mikrotik"
+)
+
+//go:generate mockgen -destination mocks_test.go -package mikrotik -typed . DNSClient,AddressListClient,NATClient
+
+// Client is the interface the listener uses to manage MikroTik resources.
 // mtkapi.Client satisfies this interface.
+type Client interface {
+	DNSClient
+	AddressListClient
+	NATClient
+}
+
+// DNSClient is the interface the listener uses to manage MikroTik DNS records.
 type DNSClient interface {
-	CreateDNSRecord(record mtkapi.DNSRecord) (mtkapi.DNSRecord, error)
-	UpdateDNSRecord(record mtkapi.DNSRecord) (mtkapi.DNSRecord, error)
-	FindDNSRecords(filter mtkapi.DNSRecord) ([]mtkapi.DNSRecord, error)
-	DeleteDNSRecord(id string) error
+	CreateDNSRecord(ctx context.Context, record mtkapi.DNSRecord) (mtkapi.DNSRecord, error)
+	UpdateDNSRecord(ctx context.Context, record mtkapi.DNSRecord) (mtkapi.DNSRecord, error)
+	FindDNSRecords(ctx context.Context, filter mtkapi.DNSRecord) ([]mtkapi.DNSRecord, error)
+	DeleteDNSRecord(ctx context.Context, id string) error
+}
+
+// AddressListClient is the interface the listener uses to manage MikroTik firewall address lists.
+type AddressListClient interface {
+	CreateAddressListEntry(ctx context.Context, entry mtkapi.AddressListEntry) (mtkapi.AddressListEntry, error)
+	UpdateAddressListEntry(ctx context.Context, entry mtkapi.AddressListEntry) (mtkapi.AddressListEntry, error)
+	FindAddressListEntries(ctx context.Context, filter mtkapi.AddressListEntry) ([]mtkapi.AddressListEntry, error)
+	DeleteAddressListEntry(ctx context.Context, id string) error
+}
+
+// NATClient is the interface the listener uses to manage MikroTik firewall NAT rules.
+type NATClient interface {
+	CreateNATRule(ctx context.Context, rule mtkapi.NATRule) (mtkapi.NATRule, error)
+	UpdateNATRule(ctx context.Context, rule mtkapi.NATRule) (mtkapi.NATRule, error)
+	FindNATRules(ctx context.Context, filter mtkapi.NATRule) ([]mtkapi.NATRule, error)
+	DeleteNATRule(ctx context.Context, id string) error
+}
+
+// LeaseClient is the interface the lease importer uses to read MikroTik DHCP leases.
+type LeaseClient interface {
+	FindDHCPLeases(ctx context.Context, filter mtkapi.DHCPLease) ([]mtkapi.DHCPLease, error)
+}
+
+// Catalog is the interface the lease importer uses to manage Consul external nodes.
+// *capi.Catalog satisfies this interface.
+type Catalog interface {
+	Nodes(q *capi.QueryOptions) ([]*capi.Node, *capi.QueryMeta, error)
+	Register(reg *capi.CatalogRegistration, q *capi.WriteOptions) (*capi.WriteMeta, error)
+	Deregister(dereg *capi.CatalogDeregistration, q *capi.WriteOptions) (*capi.WriteMeta, error)
+}
+
+// KV is the interface the lease importer uses to read the safeguard confirmation key.
+// *capi.KV satisfies this interface.
+type KV interface {
+	Get(key string, q *capi.QueryOptions) (*capi.KVPair, *capi.QueryMeta, error)
 }
@@ -0,0 +1,245 @@
+package mikrotik
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	capi "github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/jfk9w/consul-publish/internal/listeners"
+	mtkapi "github.com/jfk9w/consul-publish/internal/mikrotik"
+)
+
+// Node metadata keys set on the external nodes registered from DHCP leases.
+const (
+	externalNodeKey   = "external-node"
+	externalSourceKey = "external-source"
+)
+
+var (
+	metaKeyRegexp  = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+	nodeNameRegexp = regexp.MustCompile(`[^a-z0-9-]+`)
+)
+
+// Leases configures the import of DHCP leases into the Consul catalog.
+type Leases struct {
+	Enabled  bool            `yaml:"enabled,omitempty" doc:"Register bound DHCP leases as Consul external nodes"`
+	Server   string          `yaml:"server,omitempty" doc:"Import only the leases of this DHCP server"`
+	Interval mtkapi.Duration `yaml:"interval,omitempty" default:"1m" doc:"Interval between lease imports"`
+	Source   string          `yaml:"source,omitempty" default:"mikrotik-dhcp" doc:"Value of the external-source node metadata which marks the nodes managed by the importer"`
+}
+
+// LeaseImporter registers the bound DHCP leases of the router as Consul external nodes,
+// so that devices without a Consul agent (printers, cameras) appear in consul.State.
+// Nodes are named after the lease host name and get node metadata from key=value pairs
+// in the lease comment. Nodes of expired leases are deregistered, subject to the safeguard
+// of the router under the <label>-leases confirmation key.
+type LeaseImporter struct {
+	cfg     ListenerConfig
+	client  LeaseClient
+	catalog Catalog
+	kv      KV
+	guard   *listeners.Guard
+
+	leader atomic.Bool
+	wake   chan struct{}
+}
+
+// NewLeaseImporter creates a LeaseImporter backed by a real MikroTik client.
+func NewLeaseImporter(cfg ListenerConfig, catalog Catalog, kv KV) (*LeaseImporter, error) {
+	if cfg.Leases.Interval <= 0 {
+		return nil, errors.Errorf("lease import interval must be positive, got %s", time.Duration(cfg.Leases.Interval))
+	}
+
+	client, err := mtkapi.New(cfg.Config)
+	if err != nil {
+		return nil, errors.Wrap(err, "create MikroTik client")
+	}
+
+	return NewLeaseImporterWithClient(cfg, client, catalog, kv), nil
+}
+
+// NewLeaseImporterWithClient creates a LeaseImporter with the provided clients.
+// Intended for testing.
+func NewLeaseImporterWithClient(cfg ListenerConfig, client LeaseClient, catalog Catalog, kv KV) *LeaseImporter {
+	return &LeaseImporter{
+		cfg:     cfg,
+		client:  client,
+		catalog: catalog,
+		kv:      kv,
+		guard:   listeners.NewGuard(cfg.label()+"-leases", cfg.Safeguard),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Run imports the leases every configured interval, and right after the importer becomes
+// the leader, until ctx is cancelled. Failed imports are logged and retried on the next tick.
+func (i *LeaseImporter) Run(ctx context.Context) error {
+	ticker := time.NewTicker(time.Duration(i.cfg.Leases.Interval))
+	defer ticker.Stop()
+
+	for {
+		if err := i.Import(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("failed to import DHCP leases", "error", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-i.wake:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// SetLeader updates the leadership status in cluster mode, where only the leader imports leases.
+// A new leader imports the leases right away in Run; SetLeader itself does not wait for the import.
+func (i *LeaseImporter) SetLeader(_ context.Context, leader bool) {
+	i.leader.Store(leader)
+	if !leader {
+		return
+	}
+
+	select {
+	case i.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Import synchronises the external nodes managed by the importer with the bound leases.
+// Leases whose node name is already taken by a node not managed by the importer are skipped.
+func (i *LeaseImporter) Import(ctx context.Context) error {
+	// Followers read the confirmation key as well, so that a value written before they
+	// became the leader does not confirm their first import.
+	check, err := i.guard.BeginWith(func(key string) ([]byte, error) {
+		pair, _, err := i.kv.Get(key, (&capi.QueryOptions{}).WithContext(ctx))
+		if err != nil || pair == nil {
+			return nil, err
+		}
+
+		return pair.Value, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if i.cfg.Cluster.Enabled && !i.leader.Load() {
+		slog.Debug("not the leader, skipping DHCP lease import")
+		return nil
+	}
+
+	leases, err := i.client.FindDHCPLeases(ctx, mtkapi.DHCPLease{Server: i.cfg.Leases.Server, Status: mtkapi.DHCPLeaseBound})
+	if err != nil {
+		return errors.Wrap(err, "fetch DHCP leases")
+	}
+
+	nodes, _, err := i.catalog.Nodes((&capi.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, "fetch catalog nodes")
+	}
+
+	managed := make(map[string]*capi.Node)
+	taken := make(map[string]bool)
+	for _, node := range nodes {
+		if node.Meta[externalSourceKey] == i.cfg.Leases.Source {
+			managed[node.Node] = node
+		} else {
+			taken[node.Node] = true
+		}
+	}
+
+	desired := make(map[string]bool)
+	for _, lease := range leases {
+		name := leaseNodeName(lease)
+		switch {
+		case lease.Address == "":
+			continue
+		case desired[name]:
+			slog.Warn("duplicate DHCP lease node name, skipping", "node", name, "address", lease.Address)
+			continue
+		case taken[name]:
+			slog.Warn("DHCP lease node name is taken by another node, skipping", "node", name, "address", lease.Address)
+			continue
+		}
+
+		desired[name] = true
+		meta := i.leaseMeta(lease)
+		if node := managed[name]; node != nil && node.Address == lease.Address && maps.Equal(node.Meta, meta) {
+			continue
+		}
+
+		slog.Info("registering external node", "node", name, "address", lease.Address)
+		if _, err := i.catalog.Register(&capi.CatalogRegistration{
+			Node:     name,
+			Address:  lease.Address,
+			NodeMeta: meta,
+		}, (&capi.WriteOptions{}).WithContext(ctx)); err != nil {
+			return errors.Wrapf(err, "register node %s", name)
+		}
+	}
+
+	removed := 0
+	for name := range managed {
+		if !desired[name] {
+			removed++
+		}
+	}
+
+	if !check.Allow("external node", len(managed), removed) {
+		return nil
+	}
+
+	for name := range managed {
+		if desired[name] {
+			continue
+		}
+
+		slog.Info("deregistering external node", "node", name)
+		if _, err := i.catalog.Deregister(&capi.CatalogDeregistration{Node: name}, (&capi.WriteOptions{}).WithContext(ctx)); err != nil {
+			return errors.Wrapf(err, "deregister node %s", name)
+		}
+	}
+
+	return nil
+}
+
+// leaseMeta returns the node metadata for lease. The comment is parsed as space-separated
+// key=value pairs; repeated keys are joined with spaces, so "groups=iot groups=cameras"
+// puts the node into both groups.
+func (i *LeaseImporter) leaseMeta(lease mtkapi.DHCPLease) map[string]string {
+	meta := make(map[string]string)
+	for _, field := range strings.Fields(lease.Comment) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || !metaKeyRegexp.MatchString(key) {
+			slog.Warn("ignoring invalid DHCP lease comment field", "address", lease.Address, "field", field)
+			continue
+		}
+
+		if meta[key] != "" {
+			value = meta[key] + " " + value
+		}
+
+		meta[key] = value
+	}
+
+	meta[externalNodeKey] = "true"
+	meta[externalSourceKey] = i.cfg.Leases.Source
+	return meta
+}
+
+// leaseNodeName returns the node name for lease: the host name reduced to lowercase
+// letters, digits and dashes, or dhcp-<mac> for leases without a usable host name.
+func leaseNodeName(lease mtkapi.DHCPLease) string {
+	name := strings.Trim(nodeNameRegexp.ReplaceAllString(strings.ToLower(lease.HostName), "-"), "-")
+	if name != "" {
+		return name
+	}
+
+	return "dhcp-" + strings.ToLower(strings.ReplaceAll(lease.MACAddress, ":", "-"))
+}
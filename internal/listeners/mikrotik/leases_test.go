@@ -0,0 +1,198 @@
+package mikrotik_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	capi "github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jfk9w/consul-publish/internal/listeners"
+	"github.com/jfk9w/consul-publish/internal/listeners/mikrotik"
+	mtkapi "github.com/jfk9w/consul-publish/internal/mikrotik"
+	"github.com/jfk9w/consul-publish/internal/mikrotik/mikrotiktest"
+)
+
+// fakeCatalog is an in-memory Consul catalog of nodes.
+type fakeCatalog struct {
+	mu        sync.Mutex
+	nodes     map[string]*capi.Node
+	registers int
+}
+
+func (c *fakeCatalog) Nodes(*capi.QueryOptions) ([]*capi.Node, *capi.QueryMeta, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	nodes := make([]*capi.Node, 0, len(c.nodes))
+	for _, node := range c.nodes {
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil, nil
+}
+
+func (c *fakeCatalog) Register(reg *capi.CatalogRegistration, _ *capi.WriteOptions) (*capi.WriteMeta, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.registers++
+	c.nodes[reg.Node] = &capi.Node{Node: reg.Node, Address: reg.Address, Meta: reg.NodeMeta}
+	return nil, nil
+}
+
+func (c *fakeCatalog) Deregister(dereg *capi.CatalogDeregistration, _ *capi.WriteOptions) (*capi.WriteMeta, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.nodes, dereg.Node)
+	return nil, nil
+}
+
+// fakeKV is an in-memory Consul KV store.
+type fakeKV map[string][]byte
+
+func (kv fakeKV) Get(key string, _ *capi.QueryOptions) (*capi.KVPair, *capi.QueryMeta, error) {
+	value, ok := kv[key]
+	if !ok {
+		return nil, nil, nil
+	}
+
+	return &capi.KVPair{Key: key, Value: value}, nil, nil
+}
+
+func TestLeaseImporter_Import(t *testing.T) {
+	srv := mikrotiktest.NewServer()
+	defer srv.Close()
+
+	srv.Add(mikrotiktest.DHCPLease, mtkapi.DHCPLease{
+		Address:    "10.0.0.10",
+		MACAddress: "AA:BB:CC:00:00:10",
+		HostName:   "Office Printer",
+		Status:     mtkapi.DHCPLeaseBound,
+		Comment:    "domain-name=printer.lan groups=iot groups=office",
+	})
+	srv.Add(mikrotiktest.DHCPLease, mtkapi.DHCPLease{
+		Address:    "10.0.0.11",
+		MACAddress: "AA:BB:CC:00:00:11",
+		Status:     mtkapi.DHCPLeaseBound,
+	})
+	srv.Add(mikrotiktest.DHCPLease, mtkapi.DHCPLease{
+		Address:    "10.0.0.12",
+		MACAddress: "AA:BB:CC:00:00:12",
+		HostName:   "camera",
+		Status:     "waiting",
+	})
+	srv.Add(mikrotiktest.DHCPLease, mtkapi.DHCPLease{
+		Address:    "10.0.0.13",
+		MACAddress: "AA:BB:CC:00:00:13",
+		HostName:   "node1",
+		Status:     mtkapi.DHCPLeaseBound,
+	})
+
+	external := map[string]string{"external-node": "true", "external-source": "mikrotik-dhcp"}
+	catalog := &fakeCatalog{nodes: map[string]*capi.Node{
+		"node1":  {Node: "node1", Address: "10.0.0.1"},
+		"camera": {Node: "camera", Address: "10.0.0.12", Meta: external},
+	}}
+
+	cfg := mikrotik.ListenerConfig{Config: srv.Config()}
+	cfg.Leases.Source = "mikrotik-dhcp"
+	cfg.Leases.Interval = mtkapi.Duration(time.Minute)
+	importer, err := mikrotik.NewLeaseImporter(cfg, catalog, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, importer.Import(context.Background()))
+	require.Equal(t, map[string]*capi.Node{
+		"node1": {Node: "node1", Address: "10.0.0.1"},
+		"office-printer": {Node: "office-printer", Address: "10.0.0.10", Meta: map[string]string{
+			"external-node":   "true",
+			"external-source": "mikrotik-dhcp",
+			"domain-name":     "printer.lan",
+			"groups":          "iot office",
+		}},
+		"dhcp-aa-bb-cc-00-00-11": {Node: "dhcp-aa-bb-cc-00-00-11", Address: "10.0.0.11", Meta: external},
+	}, catalog.nodes)
+	require.Equal(t, 2, catalog.registers)
+
+	// Unchanged leases are not registered again.
+	require.NoError(t, importer.Import(context.Background()))
+	require.Equal(t, 2, catalog.registers)
+}
+
+func TestLeaseImporter_SafeguardBlocksMassDeregistration(t *testing.T) {
+	srv := mikrotiktest.NewServer()
+	defer srv.Close()
+
+	external := map[string]string{"external-node": "true", "external-source": "mikrotik-dhcp"}
+	catalog := &fakeCatalog{nodes: map[string]*capi.Node{
+		"printer": {Node: "printer", Address: "10.0.0.10", Meta: external},
+		"camera":  {Node: "camera", Address: "10.0.0.11", Meta: external},
+	}}
+
+	kv := fakeKV{}
+	cfg := mikrotik.ListenerConfig{Config: srv.Config(), Safeguard: listeners.Safeguard{MaxDeletes: 1, Confirm: "confirm"}}
+	cfg.Leases.Source = "mikrotik-dhcp"
+	cfg.Leases.Interval = mtkapi.Duration(time.Minute)
+	importer, err := mikrotik.NewLeaseImporter(cfg, catalog, kv)
+	require.NoError(t, err)
+
+	// The router returns no bound leases, which would deregister every managed node.
+	require.NoError(t, importer.Import(context.Background()))
+	require.Len(t, catalog.nodes, 2)
+
+	kv["confirm/mikrotik-leases"] = []byte("yes")
+	require.NoError(t, importer.Import(context.Background()))
+	require.Empty(t, catalog.nodes)
+}
+
+func TestLeaseImporter_ClusterFollowerSkips(t *testing.T) {
+	catalog := &fakeCatalog{nodes: map[string]*capi.Node{}}
+	// The nil client panics if a follower requests the leases.
+	importer := mikrotik.NewLeaseImporterWithClient(mikrotik.ListenerConfig{
+		Cluster: mikrotik.Cluster{Enabled: true},
+	}, nil, catalog, nil)
+
+	require.NoError(t, importer.Import(context.Background()))
+	require.Empty(t, catalog.nodes)
+}
+
+func TestLeaseImporter_ImportsAfterElection(t *testing.T) {
+	srv := mikrotiktest.NewServer()
+	defer srv.Close()
+
+	srv.Add(mikrotiktest.DHCPLease, mtkapi.DHCPLease{
+		Address:    "10.0.0.10",
+		MACAddress: "AA:BB:CC:00:00:10",
+		HostName:   "printer",
+		Status:     mtkapi.DHCPLeaseBound,
+	})
+
+	catalog := &fakeCatalog{nodes: map[string]*capi.Node{}}
+	importer, err := mikrotik.NewLeaseImporter(mikrotik.ListenerConfig{
+		Config:  srv.Config(),
+		Cluster: mikrotik.Cluster{Enabled: true},
+		Leases:  mikrotik.Leases{Enabled: true, Interval: mtkapi.Duration(time.Hour), Source: "mikrotik-dhcp"},
+	}, catalog, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- importer.Run(ctx) }()
+
+	// The new leader does not wait for the next tick an hour later.
+	importer.SetLeader(ctx, true)
+	require.Eventually(t, func() bool {
+		catalog.mu.Lock()
+		defer catalog.mu.Unlock()
+		return catalog.nodes["printer"] != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestNewLeaseImporter_InvalidInterval(t *testing.T) {
+	_, err := mikrotik.NewLeaseImporter(mikrotik.ListenerConfig{Leases: mikrotik.Leases{Enabled: true}}, &fakeCatalog{}, nil)
+	require.ErrorContains(t, err, "interval")
+}
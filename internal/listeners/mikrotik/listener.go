@@ -3,10 +3,18 @@ package mikrotik
 import (
 	"context"
 	"log/slog"
+	"maps"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/jfk9w/consul-publish/internal/consul"
+	"github.com/jfk9w/consul-publish/internal/lib"
 	"github.com/jfk9w/consul-publish/internal/listeners"
 	mtkapi "github.com/jfk9w/consul-publish/internal/mikrotik"
 )
@@ -14,132 +22,335 @@ import (
 // ListenerConfig holds configuration for the MikroTik DNS listener.
 type ListenerConfig struct {
 	mtkapi.Config `yaml:",inline"`
-	TTL           mtkapi.Duration `yaml:"ttl"     default:"5m"    doc:"DNS record TTL"`
-	Comment       string          `yaml:"comment" default:"consul" doc:"Comment used to tag records managed by this listener; only records with this comment are reconciled"`
+	TTL           mtkapi.Duration     `yaml:"ttl"     default:"5m"    doc:"DNS record TTL"`
+	Comment       string              `yaml:"comment" default:"consul" doc:"Comment used to tag records managed by this listener; only records with this comment are reconciled"`
+	Group         string              `yaml:"group,omitempty" doc:"Publish only the records of the nodes in this group; all nodes by default"`
+	CNAME         string              `yaml:"cname,omitempty" doc:"Domain suffix of node names; when set, domains are published as CNAME records to <node>.<suffix>, and every node gets an address record under the suffix"`
+	Cluster       Cluster             `yaml:"cluster,omitempty" doc:"Cluster mode settings"`
+	AddressLists  AddressLists        `yaml:"address-lists,omitempty" doc:"Firewall address list synchronisation settings"`
+	NAT           NAT                 `yaml:"nat,omitempty" doc:"Port forwarding settings"`
+	Leases        Leases              `yaml:"leases,omitempty" doc:"DHCP lease import settings"`
+	Safeguard     listeners.Safeguard `yaml:"safeguard,omitempty" doc:"Protection against changes that delete most of the managed entries at once"`
+
+	name string // router name, empty for the top-level router
+}
+
+// label returns the listener name used in metrics and as the safeguard confirmation key.
+func (c ListenerConfig) label() string {
+	if c.name == "" {
+		return "mikrotik"
+	}
+
+	return "mikrotik-" + c.name
+}
+
+// Cluster configures cluster mode, in which a single node elected with a Consul lock
+// manages the records of all nodes.
+type Cluster struct {
+	Enabled bool            `yaml:"enabled,omitempty" doc:"Reconcile records for the services and domain names of all nodes from a single elected node"`
+	Lock    string          `yaml:"lock,omitempty" default:"consul-publish/mikrotik/leader" doc:"Consul KV key used as the leader election lock"`
+	Retry   mtkapi.Duration `yaml:"retry,omitempty" default:"10s" doc:"Delay before retrying a failed attempt to acquire the leader election lock"`
 }
 
 type Listener struct {
 	cfg    ListenerConfig
-	client DNSClient
+	client Client
+	guard  *listeners.Guard
+
+	mu     sync.Mutex
+	leader bool
+	state  *consul.State
 }
 
 // NewListener creates a Listener backed by a real MikroTik client.
-func NewListener(cfg ListenerConfig) *Listener {
-	return NewListenerWithClient(cfg, mtkapi.New(cfg.Config))
+func NewListener(cfg ListenerConfig) (*Listener, error) {
+	if err := cfg.NAT.validate(); err != nil {
+		return nil, err
+	}
+
+	client, err := mtkapi.New(cfg.Config)
+	if err != nil {
+		return nil, errors.Wrap(err, "create MikroTik client")
+	}
+
+	return NewListenerWithClient(cfg, client), nil
 }
 
-// NewListenerWithClient creates a Listener with the provided DNSClient.
+// NewListenerWithClient creates a Listener with the provided Client.
 // Intended for testing.
-func NewListenerWithClient(cfg ListenerConfig, client DNSClient) *Listener {
-	return &Listener{cfg: cfg, client: client}
+func NewListenerWithClient(cfg ListenerConfig, client Client) *Listener {
+	return &Listener{cfg: cfg, client: client, guard: listeners.NewGuard(cfg.label(), cfg.Safeguard)}
 }
 
 func (l *Listener) KV() []string {
-	return nil
+	return l.guard.KV()
 }
 
 // Notify reconciles MikroTik static DNS records with the current Consul state.
 // For every service that has a "domain-name" metadata key, a DNS record pointing
-// to the current node's IP is created or updated. Records with the configured
+// to the current node is created or updated: an A or AAAA record with its IP, or a CNAME
+// record with its name when CNAME is set. Records with the configured
 // comment that are no longer present in Consul are deleted.
+//
+// In cluster mode records are built for all nodes, and only the elected leader
+// talks to the router; other nodes just remember the state for a later failover.
 func (l *Listener) Notify(ctx context.Context, state *consul.State) error {
-	selfNode := state.Nodes[state.Self]
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
-	desired := make(map[string]string)
-	for _, service := range selfNode.Services {
-		for _, domain := range listeners.GetDomainNames(service.Meta) {
-			desired[domain] = selfNode.Address
+	// Followers track the confirmation key as well, so that a value written before
+	// a failover does not confirm the first reconciliation of the new leader.
+	check := l.guard.Begin(state)
+	if l.cfg.Cluster.Enabled {
+		l.state = state
+		if !l.leader {
+			slog.Debug("not the leader, skipping DNS reconciliation", "listener", "mikrotik")
+			return nil
 		}
 	}
 
-	existing, err := l.fetchExisting()
-	if err != nil {
-		return errors.Wrap(err, "fetch existing DNS records")
+	return l.reconcile(ctx, state, check)
+}
+
+// SetLeader updates the leadership status in cluster mode. A node that becomes the leader
+// immediately reconciles the last known state, so that records are not left stale until
+// the next Consul change.
+func (l *Listener) SetLeader(ctx context.Context, leader bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.leader = leader
+	if !leader || l.state == nil {
+		return
 	}
 
-	for domain, address := range desired {
-		if err := l.reconcileDomain(domain, address, existing[domain]); err != nil {
-			return errors.Wrapf(err, "reconcile domain %s", domain)
-		}
+	if err := l.reconcile(ctx, l.state, l.guard.Begin(l.state)); err != nil {
+		slog.Error("failed to reconcile DNS records after leader election", "listener", "mikrotik", "error", err)
 	}
+}
 
-	for domain, records := range existing {
-		if _, ok := desired[domain]; ok {
-			continue
+func (l *Listener) reconcile(ctx context.Context, state *consul.State, check *listeners.Check) error {
+	if err := l.dnsRecords(check).reconcile(ctx, l.desired(state)); err != nil {
+		return err
+	}
+
+	if l.cfg.AddressLists.Enabled {
+		if err := l.addressListEntries(check).reconcile(ctx, l.desiredEntries(state)); err != nil {
+			return err
 		}
-		for _, r := range records {
-			slog.Info("deleting DNS record", "domain", domain, "id", r.ID)
-			if err := l.client.DeleteDNSRecord(r.ID); err != nil {
-				return errors.Wrapf(err, "delete DNS record %s (id=%s)", domain, r.ID)
-			}
+	}
+
+	if l.cfg.NAT.Enabled {
+		if err := l.natRules(check).reconcile(ctx, l.desiredRules(state)); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-func (l *Listener) fetchExisting() (map[string][]mtkapi.DNSRecord, error) {
-	records, err := l.client.FindDNSRecords(mtkapi.DNSRecord{Comment: l.cfg.Comment})
-	if err != nil {
-		return nil, err
-	}
-	m := make(map[string][]mtkapi.DNSRecord, len(records))
-	for _, r := range records {
-		m[r.Name] = append(m[r.Name], r)
+// dnsRecords returns the reconciler of the static DNS records tagged with the configured comment.
+func (l *Listener) dnsRecords(check *listeners.Check) reconciler[mtkapi.DNSRecord] {
+	return reconciler[mtkapi.DNSRecord]{
+		kind: "DNS record",
+		key:  recordKey,
+		name: recordName,
+		equal: func(desired, existing mtkapi.DNSRecord) bool {
+			// The TTL is compared only when the router reports it.
+			return desired.Data() == existing.Data() && (existing.TTL == 0 || existing.TTL == desired.TTL)
+		},
+		id: func(record mtkapi.DNSRecord) string { return record.ID },
+		find: func(ctx context.Context) ([]mtkapi.DNSRecord, error) {
+			return l.client.FindDNSRecords(ctx, mtkapi.DNSRecord{Comment: l.cfg.Comment})
+		},
+		create: func(ctx context.Context, record mtkapi.DNSRecord) error {
+			record.Comment = l.cfg.Comment
+			_, err := l.client.CreateDNSRecord(ctx, record)
+			return err
+		},
+		update: func(ctx context.Context, id string, record mtkapi.DNSRecord) error {
+			record.ID, record.Comment = id, l.cfg.Comment
+			_, err := l.client.UpdateDNSRecord(ctx, record)
+			return err
+		},
+		allow:  check.Allow,
+		delete: l.client.DeleteDNSRecord,
 	}
-	return m, nil
 }
 
-// reconcileDomain brings the MikroTik records for a single domain name into the
-// desired state: exactly one record pointing to address. If multiple records exist,
-// duplicates are deleted; if the address is wrong, the record is updated in-place.
-func (l *Listener) reconcileDomain(domain, address string, existing []mtkapi.DNSRecord) error {
-	matchIdx := -1
-	for i, r := range existing {
-		if r.Address == address {
-			matchIdx = i
-			break
+// desired returns the desired records keyed by recordKey. The domain names of nodes and their
+// services are published; outside of cluster mode only the local node is considered.
+//
+// Node domain names take precedence over service domain names, and among equal candidates
+// the node that comes first by name wins, so that every leader computes the same records.
+func (l *Listener) desired(state *consul.State) map[string]mtkapi.DNSRecord {
+	desired := make(map[string]mtkapi.DNSRecord)
+	owners := make(map[string]string)
+	published := make(lib.Set[string])
+	add := func(node consul.Node, record mtkapi.DNSRecord) {
+		key := recordKey(record)
+		if owner, ok := owners[key]; ok {
+			if desired[key].Data() != record.Data() {
+				slog.Debug("conflicting DNS record", "domain", recordName(record), "node", node.Name, "owner", owner)
+			}
+
+			return
 		}
+
+		desired[key] = record
+		owners[key] = node.Name
+		published.Add(node.Name)
 	}
 
-	keepIdx := matchIdx
-	if keepIdx < 0 {
-		keepIdx = 0
+	publish := func(node consul.Node, meta map[string]string, tagged ...map[string]string) {
+		domains := listeners.GetDomainNames(meta)
+		if len(domains) == 0 || listeners.IsMikrotikDisabled(meta) {
+			return
+		}
+
+		target, ok := l.target(node, meta, tagged...)
+		if !ok {
+			return
+		}
+
+		for _, domain := range domains {
+			add(node, withDomain(target, domain))
+		}
 	}
-	for i, r := range existing {
-		if i == keepIdx {
-			continue
+
+	nodes := l.nodes(state)
+	for _, node := range nodes {
+		publish(node, node.Meta, node.TaggedAddresses)
+	}
+
+	for _, node := range nodes {
+		for _, service := range node.Services {
+			publish(node, service.Meta, service.TaggedAddresses, node.TaggedAddresses)
 		}
-		slog.Info("deleting duplicate DNS record", "domain", domain, "id", r.ID)
-		if err := l.client.DeleteDNSRecord(r.ID); err != nil {
-			return errors.Wrapf(err, "delete duplicate record id=%s", r.ID)
+	}
+
+	if l.cfg.CNAME != "" {
+		// CNAME targets must resolve, so every node that owns a record gets an address record.
+		for _, node := range nodes {
+			if published[node.Name] {
+				record := addressRecord(l.nodeDomain(node), node.Address)
+				record.TTL = l.cfg.TTL
+				add(node, record)
+			}
 		}
 	}
 
-	switch {
-	case len(existing) == 0:
-		slog.Info("creating DNS record", "domain", domain, "address", address)
-		_, err := l.client.CreateDNSRecord(mtkapi.DNSRecord{
-			Name:    domain,
-			Address: address,
-			TTL:     l.cfg.TTL,
-			Comment: l.cfg.Comment,
-		})
-		return errors.Wrap(err, "create DNS record")
-
-	case matchIdx < 0:
-		kept := existing[keepIdx]
-		slog.Info("updating DNS record", "domain", domain, "id", kept.ID, "old", kept.Address, "new", address)
-		_, err := l.client.UpdateDNSRecord(mtkapi.DNSRecord{
-			ID:      kept.ID,
-			Name:    domain,
-			Address: address,
-			TTL:     l.cfg.TTL,
-			Comment: l.cfg.Comment,
-		})
-		return errors.Wrap(err, "update DNS record")
+	return desired
+}
+
+// nodes returns the nodes managed by this listener ordered by name: all nodes in cluster mode
+// and only the local node otherwise, limited to the members of the configured group.
+func (l *Listener) nodes(state *consul.State) []consul.Node {
+	names := []string{state.Self}
+	if l.cfg.Cluster.Enabled {
+		names = slices.Sorted(maps.Keys(state.Nodes))
+	}
 
+	nodes := make([]consul.Node, 0, len(names))
+	for _, name := range names {
+		if l.cfg.Group != "" && !state.Group(l.cfg.Group)[name] {
+			continue
+		}
+
+		nodes = append(nodes, state.Nodes[name])
+	}
+
+	return nodes
+}
+
+// target returns the record without a name for the domains in meta: an address record for the
+// mikrotik-address metadata, which is either an address tag looked up in tagged or a literal address,
+// and otherwise a record pointing at node. The TTL comes from the mikrotik-ttl metadata.
+// It reports false if the address cannot be resolved.
+func (l *Listener) target(node consul.Node, meta map[string]string, tagged ...map[string]string) (mtkapi.DNSRecord, bool) {
+	log := slog.With("listener", "mikrotik", "node", node.Name, "domain", meta[listeners.DomainNameKey])
+
+	var record mtkapi.DNSRecord
+	switch value := meta[listeners.MikrotikAddressKey]; {
+	case value != "":
+		address, ok := lookupAddress(value, tagged...)
+		if !ok {
+			log.Warn("unknown address tag, skipping DNS records", "address", value)
+			return record, false
+		}
+
+		record = addressRecord("", address)
+	case l.cfg.CNAME != "":
+		record = mtkapi.DNSRecord{Type: mtkapi.RecordCNAME, CName: l.nodeDomain(node)}
 	default:
-		return nil
+		record = addressRecord("", node.Address)
+	}
+
+	record.TTL = l.cfg.TTL
+	if value := meta[listeners.MikrotikTTLKey]; value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil || ttl <= 0 {
+			log.Warn("invalid DNS record TTL, using the default", "ttl", value)
+		} else {
+			record.TTL = mtkapi.Duration(ttl)
+		}
+	}
+
+	return record, true
+}
+
+// lookupAddress resolves value as an address tag in the first of tagged that has it,
+// or as a literal IP address.
+func lookupAddress(value string, tagged ...map[string]string) (string, bool) {
+	for _, addresses := range tagged {
+		if address, ok := addresses[value]; ok && address != "" {
+			return address, true
+		}
+	}
+
+	if _, err := netip.ParseAddr(value); err == nil {
+		return value, true
 	}
+
+	return "", false
+}
+
+// withDomain returns record publishing domain. Wildcard domains such as
+// *.apps.example.com become regexp records.
+func withDomain(record mtkapi.DNSRecord, domain string) mtkapi.DNSRecord {
+	if parent, ok := strings.CutPrefix(domain, "*."); ok {
+		record.Regexp = `^.+\.` + regexp.QuoteMeta(parent) + `$`
+	} else {
+		record.Name = domain
+	}
+
+	return record
+}
+
+func (l *Listener) nodeDomain(node consul.Node) string {
+	return node.Name + "." + strings.Trim(l.cfg.CNAME, ".")
+}
+
+// addressRecord returns an AAAA record for IPv6 addresses and an A record otherwise.
+// A records are created without an explicit type, like RouterOS itself reports them.
+func addressRecord(name, address string) mtkapi.DNSRecord {
+	record := mtkapi.DNSRecord{Name: name, Address: address}
+	if addr, err := netip.ParseAddr(address); err == nil && addr.Is6() && !addr.Is4In6() {
+		record.Type = mtkapi.RecordAAAA
+	}
+
+	return record
+}
+
+// recordKey identifies the records that describe the same entry: a type and either a name or a regexp.
+func recordKey(record mtkapi.DNSRecord) string {
+	return record.RecordType() + "\x00" + record.Name + "\x00" + record.Regexp
+}
+
+func recordName(record mtkapi.DNSRecord) string {
+	if record.Regexp != "" {
+		return record.Regexp
+	}
+
+	return record.Name
 }
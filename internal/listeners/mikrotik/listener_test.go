@@ -17,6 +17,13 @@ const testComment = "consul"
 
 var testTTL = mtkapi.Duration(5 * time.Minute)
 
+// mockClient combines the per-resource mocks into a mikrotik.Client.
+type mockClient struct {
+	*mikrotik.MockDNSClient
+	*mikrotik.MockAddressListClient
+	*mikrotik.MockNATClient
+}
+
 func newMockListener(t *testing.T) (*mikrotik.Listener, *mikrotik.MockDNSClient) {
 	t.Helper()
 	ctrl := gomock.NewController(t)
@@ -24,7 +31,7 @@ func newMockListener(t *testing.T) (*mikrotik.Listener, *mikrotik.MockDNSClient)
 	l := mikrotik.NewListenerWithClient(mikrotik.ListenerConfig{
 		TTL:     testTTL,
 		Comment: testComment,
-	}, m)
+	}, mockClient{m, mikrotik.NewMockAddressListClient(ctrl), mikrotik.NewMockNATClient(ctrl)})
 	return l, m
 }
 
@@ -57,8 +64,8 @@ func recordOf(id, name, address string) mtkapi.DNSRecord {
 func TestListener_Notify_CreateRecord(t *testing.T) {
 	l, m := newMockListener(t)
 
-	m.EXPECT().FindDNSRecords(mtkapi.DNSRecord{Comment: testComment}).Return(nil, nil)
-	m.EXPECT().CreateDNSRecord(mtkapi.DNSRecord{
+	m.EXPECT().FindDNSRecords(gomock.Any(), mtkapi.DNSRecord{Comment: testComment}).Return(nil, nil)
+	m.EXPECT().CreateDNSRecord(gomock.Any(), mtkapi.DNSRecord{
 		Name:    "svc.local",
 		Address: "10.0.0.1",
 		TTL:     testTTL,
@@ -71,7 +78,7 @@ func TestListener_Notify_CreateRecord(t *testing.T) {
 func TestListener_Notify_NoOp(t *testing.T) {
 	l, m := newMockListener(t)
 
-	m.EXPECT().FindDNSRecords(mtkapi.DNSRecord{Comment: testComment}).
+	m.EXPECT().FindDNSRecords(gomock.Any(), mtkapi.DNSRecord{Comment: testComment}).
 		Return([]mtkapi.DNSRecord{recordOf("*1", "svc.local", "10.0.0.1")}, nil)
 
 	require.NoError(t, l.Notify(context.Background(), stateWithServices("10.0.0.1", service("svc.local"))))
@@ -80,9 +87,9 @@ func TestListener_Notify_NoOp(t *testing.T) {
 func TestListener_Notify_UpdateRecord(t *testing.T) {
 	l, m := newMockListener(t)
 
-	m.EXPECT().FindDNSRecords(mtkapi.DNSRecord{Comment: testComment}).
+	m.EXPECT().FindDNSRecords(gomock.Any(), mtkapi.DNSRecord{Comment: testComment}).
 		Return([]mtkapi.DNSRecord{recordOf("*1", "svc.local", "10.0.0.2")}, nil)
-	m.EXPECT().UpdateDNSRecord(mtkapi.DNSRecord{
+	m.EXPECT().UpdateDNSRecord(gomock.Any(), mtkapi.DNSRecord{
 		ID:      "*1",
 		Name:    "svc.local",
 		Address: "10.0.0.1",
@@ -96,9 +103,9 @@ func TestListener_Notify_UpdateRecord(t *testing.T) {
 func TestListener_Notify_DeleteStaleRecord(t *testing.T) {
 	l, m := newMockListener(t)
 
-	m.EXPECT().FindDNSRecords(mtkapi.DNSRecord{Comment: testComment}).
+	m.EXPECT().FindDNSRecords(gomock.Any(), mtkapi.DNSRecord{Comment: testComment}).
 		Return([]mtkapi.DNSRecord{recordOf("*1", "stale.local", "10.0.0.1")}, nil)
-	m.EXPECT().DeleteDNSRecord("*1").Return(nil)
+	m.EXPECT().DeleteDNSRecord(gomock.Any(), "*1").Return(nil)
 
 	require.NoError(t, l.Notify(context.Background(), stateWithServices("10.0.0.1")))
 }
@@ -106,13 +113,13 @@ func TestListener_Notify_DeleteStaleRecord(t *testing.T) {
 func TestListener_Notify_DeleteDuplicates_WithMatch(t *testing.T) {
 	l, m := newMockListener(t)
 
-	m.EXPECT().FindDNSRecords(mtkapi.DNSRecord{Comment: testComment}).Return([]mtkapi.DNSRecord{
+	m.EXPECT().FindDNSRecords(gomock.Any(), mtkapi.DNSRecord{Comment: testComment}).Return([]mtkapi.DNSRecord{
 		recordOf("*1", "svc.local", "10.0.0.2"), // wrong
 		recordOf("*2", "svc.local", "10.0.0.1"), // correct — keep
 		recordOf("*3", "svc.local", "10.0.0.3"), // wrong
 	}, nil)
-	m.EXPECT().DeleteDNSRecord("*1").Return(nil)
-	m.EXPECT().DeleteDNSRecord("*3").Return(nil)
+	m.EXPECT().DeleteDNSRecord(gomock.Any(), "*1").Return(nil)
+	m.EXPECT().DeleteDNSRecord(gomock.Any(), "*3").Return(nil)
 
 	require.NoError(t, l.Notify(context.Background(), stateWithServices("10.0.0.1", service("svc.local"))))
 }
@@ -120,12 +127,12 @@ func TestListener_Notify_DeleteDuplicates_WithMatch(t *testing.T) {
 func TestListener_Notify_DeleteDuplicates_NoMatch(t *testing.T) {
 	l, m := newMockListener(t)
 
-	m.EXPECT().FindDNSRecords(mtkapi.DNSRecord{Comment: testComment}).Return([]mtkapi.DNSRecord{
+	m.EXPECT().FindDNSRecords(gomock.Any(), mtkapi.DNSRecord{Comment: testComment}).Return([]mtkapi.DNSRecord{
 		recordOf("*1", "svc.local", "10.0.0.2"), // kept, updated
 		recordOf("*2", "svc.local", "10.0.0.3"), // deleted
 	}, nil)
-	m.EXPECT().DeleteDNSRecord("*2").Return(nil)
-	m.EXPECT().UpdateDNSRecord(mtkapi.DNSRecord{
+	m.EXPECT().DeleteDNSRecord(gomock.Any(), "*2").Return(nil)
+	m.EXPECT().UpdateDNSRecord(gomock.Any(), mtkapi.DNSRecord{
 		ID:      "*1",
 		Name:    "svc.local",
 		Address: "10.0.0.1",
@@ -139,10 +146,10 @@ func TestListener_Notify_DeleteDuplicates_NoMatch(t *testing.T) {
 func TestListener_Notify_MultipleServices(t *testing.T) {
 	l, m := newMockListener(t)
 
-	m.EXPECT().FindDNSRecords(mtkapi.DNSRecord{Comment: testComment}).Return(nil, nil)
-	m.EXPECT().CreateDNSRecord(mtkapi.DNSRecord{Name: "a.local", Address: "10.0.0.1", TTL: testTTL, Comment: testComment}).
+	m.EXPECT().FindDNSRecords(gomock.Any(), mtkapi.DNSRecord{Comment: testComment}).Return(nil, nil)
+	m.EXPECT().CreateDNSRecord(gomock.Any(), mtkapi.DNSRecord{Name: "a.local", Address: "10.0.0.1", TTL: testTTL, Comment: testComment}).
 		Return(recordOf("*1", "a.local", "10.0.0.1"), nil)
-	m.EXPECT().CreateDNSRecord(mtkapi.DNSRecord{Name: "b.local", Address: "10.0.0.1", TTL: testTTL, Comment: testComment}).
+	m.EXPECT().CreateDNSRecord(gomock.Any(), mtkapi.DNSRecord{Name: "b.local", Address: "10.0.0.1", TTL: testTTL, Comment: testComment}).
 		Return(recordOf("*2", "b.local", "10.0.0.1"), nil)
 
 	require.NoError(t, l.Notify(context.Background(), stateWithServices("10.0.0.1", service("a.local"), service("b.local"))))
@@ -151,7 +158,7 @@ func TestListener_Notify_MultipleServices(t *testing.T) {
 func TestListener_Notify_NoServices(t *testing.T) {
 	l, m := newMockListener(t)
 
-	m.EXPECT().FindDNSRecords(mtkapi.DNSRecord{Comment: testComment}).Return(nil, nil)
+	m.EXPECT().FindDNSRecords(gomock.Any(), mtkapi.DNSRecord{Comment: testComment}).Return(nil, nil)
 
 	require.NoError(t, l.Notify(context.Background(), stateWithServices("10.0.0.1")))
 }
@@ -181,8 +188,8 @@ func TestListener_Notify_IgnoresOtherNodes(t *testing.T) {
 		},
 	}
 
-	m.EXPECT().FindDNSRecords(mtkapi.DNSRecord{Comment: testComment}).Return(nil, nil)
-	m.EXPECT().CreateDNSRecord(mtkapi.DNSRecord{
+	m.EXPECT().FindDNSRecords(gomock.Any(), mtkapi.DNSRecord{Comment: testComment}).Return(nil, nil)
+	m.EXPECT().CreateDNSRecord(gomock.Any(), mtkapi.DNSRecord{
 		Name:    "self.local",
 		Address: "10.0.0.1",
 		TTL:     testTTL,
@@ -192,3 +199,131 @@ func TestListener_Notify_IgnoresOtherNodes(t *testing.T) {
 
 	require.NoError(t, l.Notify(context.Background(), state))
 }
+
+func clusterState() *consul.State {
+	return &consul.State{
+		Self: "node1",
+		Nodes: map[string]consul.Node{
+			"node1": {
+				ID:       "node1",
+				Name:     "node1",
+				Address:  "10.0.0.1",
+				Services: []consul.Service{service("shared.local")},
+			},
+			"node2": {
+				ID:      "node2",
+				Name:    "node2",
+				Address: "10.0.0.2",
+				Meta:    map[string]string{"domain-name": "node2.local shared.local"},
+				Services: []consul.Service{
+					service("other.local"),
+				},
+			},
+		},
+	}
+}
+
+func TestListener_Notify_ClusterFollowerSkips(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	m := mikrotik.NewMockDNSClient(ctrl)
+	l := mikrotik.NewListenerWithClient(mikrotik.ListenerConfig{
+		TTL:     testTTL,
+		Comment: testComment,
+		Cluster: mikrotik.Cluster{Enabled: true},
+	}, mockClient{m, mikrotik.NewMockAddressListClient(ctrl), mikrotik.NewMockNATClient(ctrl)})
+
+	// No client calls are expected before the node is elected.
+	require.NoError(t, l.Notify(context.Background(), clusterState()))
+}
+
+func TestListener_SetLeader_ReconcilesAllNodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	m := mikrotik.NewMockDNSClient(ctrl)
+	l := mikrotik.NewListenerWithClient(mikrotik.ListenerConfig{
+		TTL:     testTTL,
+		Comment: testComment,
+		Cluster: mikrotik.Cluster{Enabled: true},
+	}, mockClient{m, mikrotik.NewMockAddressListClient(ctrl), mikrotik.NewMockNATClient(ctrl)})
+
+	require.NoError(t, l.Notify(context.Background(), clusterState()))
+
+	m.EXPECT().FindDNSRecords(gomock.Any(), mtkapi.DNSRecord{Comment: testComment}).
+		Return([]mtkapi.DNSRecord{recordOf("*1", "other.local", "10.0.0.2")}, nil)
+	m.EXPECT().CreateDNSRecord(gomock.Any(), mtkapi.DNSRecord{Name: "node2.local", Address: "10.0.0.2", TTL: testTTL, Comment: testComment}).
+		Return(recordOf("*2", "node2.local", "10.0.0.2"), nil)
+	// Node domain names take precedence over service domain names.
+	m.EXPECT().CreateDNSRecord(gomock.Any(), mtkapi.DNSRecord{Name: "shared.local", Address: "10.0.0.2", TTL: testTTL, Comment: testComment}).
+		Return(recordOf("*3", "shared.local", "10.0.0.2"), nil)
+
+	l.SetLeader(context.Background(), true)
+
+	// The leader reconciles on every notification, followers do not.
+	m.EXPECT().FindDNSRecords(gomock.Any(), mtkapi.DNSRecord{Comment: testComment}).Return([]mtkapi.DNSRecord{
+		recordOf("*1", "other.local", "10.0.0.2"),
+		recordOf("*2", "node2.local", "10.0.0.2"),
+		recordOf("*3", "shared.local", "10.0.0.2"),
+	}, nil)
+	require.NoError(t, l.Notify(context.Background(), clusterState()))
+
+	l.SetLeader(context.Background(), false)
+	require.NoError(t, l.Notify(context.Background(), clusterState()))
+}
+
+func TestListener_Notify_AAAARecord(t *testing.T) {
+	l, m := newMockListener(t)
+
+	m.EXPECT().FindDNSRecords(gomock.Any(), mtkapi.DNSRecord{Comment: testComment}).
+		Return([]mtkapi.DNSRecord{recordOf("*1", "svc.local", "10.0.0.1")}, nil)
+	m.EXPECT().CreateDNSRecord(gomock.Any(), mtkapi.DNSRecord{
+		Name:    "svc.local",
+		Type:    mtkapi.RecordAAAA,
+		Address: "fd00::1",
+		TTL:     testTTL,
+		Comment: testComment,
+	}).Return(mtkapi.DNSRecord{ID: "*2"}, nil)
+	// The A record no longer matches the node address family.
+	m.EXPECT().DeleteDNSRecord(gomock.Any(), "*1").Return(nil)
+
+	require.NoError(t, l.Notify(context.Background(), stateWithServices("fd00::1", service("svc.local"))))
+}
+
+func TestListener_Notify_WildcardRegexpRecord(t *testing.T) {
+	l, m := newMockListener(t)
+
+	m.EXPECT().FindDNSRecords(gomock.Any(), mtkapi.DNSRecord{Comment: testComment}).Return(nil, nil)
+	m.EXPECT().CreateDNSRecord(gomock.Any(), mtkapi.DNSRecord{
+		Regexp:  `^.+\.apps\.example\.com$`,
+		Address: "10.0.0.1",
+		TTL:     testTTL,
+		Comment: testComment,
+	}).Return(mtkapi.DNSRecord{ID: "*1"}, nil)
+
+	require.NoError(t, l.Notify(context.Background(), stateWithServices("10.0.0.1", service("*.apps.example.com"))))
+}
+
+func TestListener_Notify_CNAMERecords(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	m := mikrotik.NewMockDNSClient(ctrl)
+	l := mikrotik.NewListenerWithClient(mikrotik.ListenerConfig{
+		TTL:     testTTL,
+		Comment: testComment,
+		CNAME:   "lan.",
+	}, mockClient{m, mikrotik.NewMockAddressListClient(ctrl), mikrotik.NewMockNATClient(ctrl)})
+
+	m.EXPECT().FindDNSRecords(gomock.Any(), mtkapi.DNSRecord{Comment: testComment}).Return([]mtkapi.DNSRecord{
+		{ID: "*1", Name: "svc.local", Type: mtkapi.RecordCNAME, CName: "old.lan", Comment: testComment},
+		{ID: "*2", Name: "node1.lan", Address: "10.0.0.1", Comment: testComment},
+		{ID: "*3", Name: "svc.local", Type: mtkapi.RecordTXT, Text: "stale", Comment: testComment},
+	}, nil)
+	m.EXPECT().UpdateDNSRecord(gomock.Any(), mtkapi.DNSRecord{
+		ID:      "*1",
+		Name:    "svc.local",
+		Type:    mtkapi.RecordCNAME,
+		CName:   "node1.lan",
+		TTL:     testTTL,
+		Comment: testComment,
+	}).Return(mtkapi.DNSRecord{ID: "*1"}, nil)
+	m.EXPECT().DeleteDNSRecord(gomock.Any(), "*3").Return(nil)
+
+	require.NoError(t, l.Notify(context.Background(), stateWithServices("10.0.0.1", service("svc.local"))))
+}
@@ -1,15 +1,16 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/jfk9w/consul-publish/internal/listeners/mikrotik (interfaces: DNSClient)
+// Source: github.com/jfk9w/consul-publish/internal/listeners/mikrotik (interfaces: DNSClient,AddressListClient,NATClient)
 //
 // Generated by this command:
 //
-//	mockgen -destination mocks_test.go -package mikrotik -typed . DNSClient
+//	mockgen -destination mocks_test.go -package mikrotik -typed . DNSClient,AddressListClient,NATClient
 //
 
 // Package mikrotik is a generated GoMock package.
 package mikrotik
 
 import (
+	context "context"
 	reflect "reflect"
 
 	mikrotik "github.com/jfk9w/consul-publish/internal/mikrotik"
@@ -41,18 +42,18 @@ func (m *MockDNSClient) EXPECT() *MockDNSClientMockRecorder {
 }
 
 // CreateDNSRecord mocks base method.
-func (m *MockDNSClient) CreateDNSRecord(record mikrotik.DNSRecord) (mikrotik.DNSRecord, error) {
+func (m *MockDNSClient) CreateDNSRecord(ctx context.Context, record mikrotik.DNSRecord) (mikrotik.DNSRecord, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateDNSRecord", record)
+	ret := m.ctrl.Call(m, "CreateDNSRecord", ctx, record)
 	ret0, _ := ret[0].(mikrotik.DNSRecord)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateDNSRecord indicates an expected call of CreateDNSRecord.
-func (mr *MockDNSClientMockRecorder) CreateDNSRecord(record any) *MockDNSClientCreateDNSRecordCall {
+func (mr *MockDNSClientMockRecorder) CreateDNSRecord(ctx, record any) *MockDNSClientCreateDNSRecordCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDNSRecord", reflect.TypeOf((*MockDNSClient)(nil).CreateDNSRecord), record)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDNSRecord", reflect.TypeOf((*MockDNSClient)(nil).CreateDNSRecord), ctx, record)
 	return &MockDNSClientCreateDNSRecordCall{Call: call}
 }
 
@@ -68,29 +69,29 @@ func (c *MockDNSClientCreateDNSRecordCall) Return(arg0 mikrotik.DNSRecord, arg1
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockDNSClientCreateDNSRecordCall) Do(f func(mikrotik.DNSRecord) (mikrotik.DNSRecord, error)) *MockDNSClientCreateDNSRecordCall {
+func (c *MockDNSClientCreateDNSRecordCall) Do(f func(context.Context, mikrotik.DNSRecord) (mikrotik.DNSRecord, error)) *MockDNSClientCreateDNSRecordCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockDNSClientCreateDNSRecordCall) DoAndReturn(f func(mikrotik.DNSRecord) (mikrotik.DNSRecord, error)) *MockDNSClientCreateDNSRecordCall {
+func (c *MockDNSClientCreateDNSRecordCall) DoAndReturn(f func(context.Context, mikrotik.DNSRecord) (mikrotik.DNSRecord, error)) *MockDNSClientCreateDNSRecordCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
 // DeleteDNSRecord mocks base method.
-func (m *MockDNSClient) DeleteDNSRecord(id string) error {
+func (m *MockDNSClient) DeleteDNSRecord(ctx context.Context, id string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteDNSRecord", id)
+	ret := m.ctrl.Call(m, "DeleteDNSRecord", ctx, id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteDNSRecord indicates an expected call of DeleteDNSRecord.
-func (mr *MockDNSClientMockRecorder) DeleteDNSRecord(id any) *MockDNSClientDeleteDNSRecordCall {
+func (mr *MockDNSClientMockRecorder) DeleteDNSRecord(ctx, id any) *MockDNSClientDeleteDNSRecordCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDNSRecord", reflect.TypeOf((*MockDNSClient)(nil).DeleteDNSRecord), id)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDNSRecord", reflect.TypeOf((*MockDNSClient)(nil).DeleteDNSRecord), ctx, id)
 	return &MockDNSClientDeleteDNSRecordCall{Call: call}
 }
 
@@ -106,30 +107,30 @@ func (c *MockDNSClientDeleteDNSRecordCall) Return(arg0 error) *MockDNSClientDele
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockDNSClientDeleteDNSRecordCall) Do(f func(string) error) *MockDNSClientDeleteDNSRecordCall {
+func (c *MockDNSClientDeleteDNSRecordCall) Do(f func(context.Context, string) error) *MockDNSClientDeleteDNSRecordCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockDNSClientDeleteDNSRecordCall) DoAndReturn(f func(string) error) *MockDNSClientDeleteDNSRecordCall {
+func (c *MockDNSClientDeleteDNSRecordCall) DoAndReturn(f func(context.Context, string) error) *MockDNSClientDeleteDNSRecordCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
 // FindDNSRecords mocks base method.
-func (m *MockDNSClient) FindDNSRecords(filter mikrotik.DNSRecord) ([]mikrotik.DNSRecord, error) {
+func (m *MockDNSClient) FindDNSRecords(ctx context.Context, filter mikrotik.DNSRecord) ([]mikrotik.DNSRecord, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindDNSRecords", filter)
+	ret := m.ctrl.Call(m, "FindDNSRecords", ctx, filter)
 	ret0, _ := ret[0].([]mikrotik.DNSRecord)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindDNSRecords indicates an expected call of FindDNSRecords.
-func (mr *MockDNSClientMockRecorder) FindDNSRecords(filter any) *MockDNSClientFindDNSRecordsCall {
+func (mr *MockDNSClientMockRecorder) FindDNSRecords(ctx, filter any) *MockDNSClientFindDNSRecordsCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDNSRecords", reflect.TypeOf((*MockDNSClient)(nil).FindDNSRecords), filter)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDNSRecords", reflect.TypeOf((*MockDNSClient)(nil).FindDNSRecords), ctx, filter)
 	return &MockDNSClientFindDNSRecordsCall{Call: call}
 }
 
@@ -145,30 +146,30 @@ func (c *MockDNSClientFindDNSRecordsCall) Return(arg0 []mikrotik.DNSRecord, arg1
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockDNSClientFindDNSRecordsCall) Do(f func(mikrotik.DNSRecord) ([]mikrotik.DNSRecord, error)) *MockDNSClientFindDNSRecordsCall {
+func (c *MockDNSClientFindDNSRecordsCall) Do(f func(context.Context, mikrotik.DNSRecord) ([]mikrotik.DNSRecord, error)) *MockDNSClientFindDNSRecordsCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockDNSClientFindDNSRecordsCall) DoAndReturn(f func(mikrotik.DNSRecord) ([]mikrotik.DNSRecord, error)) *MockDNSClientFindDNSRecordsCall {
+func (c *MockDNSClientFindDNSRecordsCall) DoAndReturn(f func(context.Context, mikrotik.DNSRecord) ([]mikrotik.DNSRecord, error)) *MockDNSClientFindDNSRecordsCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
 // UpdateDNSRecord mocks base method.
-func (m *MockDNSClient) UpdateDNSRecord(record mikrotik.DNSRecord) (mikrotik.DNSRecord, error) {
+func (m *MockDNSClient) UpdateDNSRecord(ctx context.Context, record mikrotik.DNSRecord) (mikrotik.DNSRecord, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateDNSRecord", record)
+	ret := m.ctrl.Call(m, "UpdateDNSRecord", ctx, record)
 	ret0, _ := ret[0].(mikrotik.DNSRecord)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // UpdateDNSRecord indicates an expected call of UpdateDNSRecord.
-func (mr *MockDNSClientMockRecorder) UpdateDNSRecord(record any) *MockDNSClientUpdateDNSRecordCall {
+func (mr *MockDNSClientMockRecorder) UpdateDNSRecord(ctx, record any) *MockDNSClientUpdateDNSRecordCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDNSRecord", reflect.TypeOf((*MockDNSClient)(nil).UpdateDNSRecord), record)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDNSRecord", reflect.TypeOf((*MockDNSClient)(nil).UpdateDNSRecord), ctx, record)
 	return &MockDNSClientUpdateDNSRecordCall{Call: call}
 }
 
@@ -184,13 +185,371 @@ func (c *MockDNSClientUpdateDNSRecordCall) Return(arg0 mikrotik.DNSRecord, arg1
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockDNSClientUpdateDNSRecordCall) Do(f func(mikrotik.DNSRecord) (mikrotik.DNSRecord, error)) *MockDNSClientUpdateDNSRecordCall {
+func (c *MockDNSClientUpdateDNSRecordCall) Do(f func(context.Context, mikrotik.DNSRecord) (mikrotik.DNSRecord, error)) *MockDNSClientUpdateDNSRecordCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockDNSClientUpdateDNSRecordCall) DoAndReturn(f func(mikrotik.DNSRecord) (mikrotik.DNSRecord, error)) *MockDNSClientUpdateDNSRecordCall {
+func (c *MockDNSClientUpdateDNSRecordCall) DoAndReturn(f func(context.Context, mikrotik.DNSRecord) (mikrotik.DNSRecord, error)) *MockDNSClientUpdateDNSRecordCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockAddressListClient is a mock of AddressListClient interface.
+type MockAddressListClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockAddressListClientMockRecorder
+	isgomock struct{}
+}
+
+// MockAddressListClientMockRecorder is the mock recorder for MockAddressListClient.
+type MockAddressListClientMockRecorder struct {
+	mock *MockAddressListClient
+}
+
+// NewMockAddressListClient creates a new mock instance.
+func NewMockAddressListClient(ctrl *gomock.Controller) *MockAddressListClient {
+	mock := &MockAddressListClient{ctrl: ctrl}
+	mock.recorder = &MockAddressListClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAddressListClient) EXPECT() *MockAddressListClientMockRecorder {
+	return m.recorder
+}
+
+// CreateAddressListEntry mocks base method.
+func (m *MockAddressListClient) CreateAddressListEntry(ctx context.Context, entry mikrotik.AddressListEntry) (mikrotik.AddressListEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAddressListEntry", ctx, entry)
+	ret0, _ := ret[0].(mikrotik.AddressListEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAddressListEntry indicates an expected call of CreateAddressListEntry.
+func (mr *MockAddressListClientMockRecorder) CreateAddressListEntry(ctx, entry any) *MockAddressListClientCreateAddressListEntryCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAddressListEntry", reflect.TypeOf((*MockAddressListClient)(nil).CreateAddressListEntry), ctx, entry)
+	return &MockAddressListClientCreateAddressListEntryCall{Call: call}
+}
+
+// MockAddressListClientCreateAddressListEntryCall wrap *gomock.Call
+type MockAddressListClientCreateAddressListEntryCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAddressListClientCreateAddressListEntryCall) Return(arg0 mikrotik.AddressListEntry, arg1 error) *MockAddressListClientCreateAddressListEntryCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAddressListClientCreateAddressListEntryCall) Do(f func(context.Context, mikrotik.AddressListEntry) (mikrotik.AddressListEntry, error)) *MockAddressListClientCreateAddressListEntryCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAddressListClientCreateAddressListEntryCall) DoAndReturn(f func(context.Context, mikrotik.AddressListEntry) (mikrotik.AddressListEntry, error)) *MockAddressListClientCreateAddressListEntryCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// DeleteAddressListEntry mocks base method.
+func (m *MockAddressListClient) DeleteAddressListEntry(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAddressListEntry", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAddressListEntry indicates an expected call of DeleteAddressListEntry.
+func (mr *MockAddressListClientMockRecorder) DeleteAddressListEntry(ctx, id any) *MockAddressListClientDeleteAddressListEntryCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAddressListEntry", reflect.TypeOf((*MockAddressListClient)(nil).DeleteAddressListEntry), ctx, id)
+	return &MockAddressListClientDeleteAddressListEntryCall{Call: call}
+}
+
+// MockAddressListClientDeleteAddressListEntryCall wrap *gomock.Call
+type MockAddressListClientDeleteAddressListEntryCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAddressListClientDeleteAddressListEntryCall) Return(arg0 error) *MockAddressListClientDeleteAddressListEntryCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAddressListClientDeleteAddressListEntryCall) Do(f func(context.Context, string) error) *MockAddressListClientDeleteAddressListEntryCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAddressListClientDeleteAddressListEntryCall) DoAndReturn(f func(context.Context, string) error) *MockAddressListClientDeleteAddressListEntryCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// FindAddressListEntries mocks base method.
+func (m *MockAddressListClient) FindAddressListEntries(ctx context.Context, filter mikrotik.AddressListEntry) ([]mikrotik.AddressListEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAddressListEntries", ctx, filter)
+	ret0, _ := ret[0].([]mikrotik.AddressListEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAddressListEntries indicates an expected call of FindAddressListEntries.
+func (mr *MockAddressListClientMockRecorder) FindAddressListEntries(ctx, filter any) *MockAddressListClientFindAddressListEntriesCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAddressListEntries", reflect.TypeOf((*MockAddressListClient)(nil).FindAddressListEntries), ctx, filter)
+	return &MockAddressListClientFindAddressListEntriesCall{Call: call}
+}
+
+// MockAddressListClientFindAddressListEntriesCall wrap *gomock.Call
+type MockAddressListClientFindAddressListEntriesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAddressListClientFindAddressListEntriesCall) Return(arg0 []mikrotik.AddressListEntry, arg1 error) *MockAddressListClientFindAddressListEntriesCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAddressListClientFindAddressListEntriesCall) Do(f func(context.Context, mikrotik.AddressListEntry) ([]mikrotik.AddressListEntry, error)) *MockAddressListClientFindAddressListEntriesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAddressListClientFindAddressListEntriesCall) DoAndReturn(f func(context.Context, mikrotik.AddressListEntry) ([]mikrotik.AddressListEntry, error)) *MockAddressListClientFindAddressListEntriesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// UpdateAddressListEntry mocks base method.
+func (m *MockAddressListClient) UpdateAddressListEntry(ctx context.Context, entry mikrotik.AddressListEntry) (mikrotik.AddressListEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAddressListEntry", ctx, entry)
+	ret0, _ := ret[0].(mikrotik.AddressListEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAddressListEntry indicates an expected call of UpdateAddressListEntry.
+func (mr *MockAddressListClientMockRecorder) UpdateAddressListEntry(ctx, entry any) *MockAddressListClientUpdateAddressListEntryCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAddressListEntry", reflect.TypeOf((*MockAddressListClient)(nil).UpdateAddressListEntry), ctx, entry)
+	return &MockAddressListClientUpdateAddressListEntryCall{Call: call}
+}
+
+// MockAddressListClientUpdateAddressListEntryCall wrap *gomock.Call
+type MockAddressListClientUpdateAddressListEntryCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAddressListClientUpdateAddressListEntryCall) Return(arg0 mikrotik.AddressListEntry, arg1 error) *MockAddressListClientUpdateAddressListEntryCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAddressListClientUpdateAddressListEntryCall) Do(f func(context.Context, mikrotik.AddressListEntry) (mikrotik.AddressListEntry, error)) *MockAddressListClientUpdateAddressListEntryCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAddressListClientUpdateAddressListEntryCall) DoAndReturn(f func(context.Context, mikrotik.AddressListEntry) (mikrotik.AddressListEntry, error)) *MockAddressListClientUpdateAddressListEntryCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockNATClient is a mock of NATClient interface.
+type MockNATClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockNATClientMockRecorder
+	isgomock struct{}
+}
+
+// MockNATClientMockRecorder is the mock recorder for MockNATClient.
+type MockNATClientMockRecorder struct {
+	mock *MockNATClient
+}
+
+// NewMockNATClient creates a new mock instance.
+func NewMockNATClient(ctrl *gomock.Controller) *MockNATClient {
+	mock := &MockNATClient{ctrl: ctrl}
+	mock.recorder = &MockNATClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNATClient) EXPECT() *MockNATClientMockRecorder {
+	return m.recorder
+}
+
+// CreateNATRule mocks base method.
+func (m *MockNATClient) CreateNATRule(ctx context.Context, rule mikrotik.NATRule) (mikrotik.NATRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNATRule", ctx, rule)
+	ret0, _ := ret[0].(mikrotik.NATRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNATRule indicates an expected call of CreateNATRule.
+func (mr *MockNATClientMockRecorder) CreateNATRule(ctx, rule any) *MockNATClientCreateNATRuleCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNATRule", reflect.TypeOf((*MockNATClient)(nil).CreateNATRule), ctx, rule)
+	return &MockNATClientCreateNATRuleCall{Call: call}
+}
+
+// MockNATClientCreateNATRuleCall wrap *gomock.Call
+type MockNATClientCreateNATRuleCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockNATClientCreateNATRuleCall) Return(arg0 mikrotik.NATRule, arg1 error) *MockNATClientCreateNATRuleCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockNATClientCreateNATRuleCall) Do(f func(context.Context, mikrotik.NATRule) (mikrotik.NATRule, error)) *MockNATClientCreateNATRuleCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockNATClientCreateNATRuleCall) DoAndReturn(f func(context.Context, mikrotik.NATRule) (mikrotik.NATRule, error)) *MockNATClientCreateNATRuleCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// DeleteNATRule mocks base method.
+func (m *MockNATClient) DeleteNATRule(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNATRule", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteNATRule indicates an expected call of DeleteNATRule.
+func (mr *MockNATClientMockRecorder) DeleteNATRule(ctx, id any) *MockNATClientDeleteNATRuleCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNATRule", reflect.TypeOf((*MockNATClient)(nil).DeleteNATRule), ctx, id)
+	return &MockNATClientDeleteNATRuleCall{Call: call}
+}
+
+// MockNATClientDeleteNATRuleCall wrap *gomock.Call
+type MockNATClientDeleteNATRuleCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockNATClientDeleteNATRuleCall) Return(arg0 error) *MockNATClientDeleteNATRuleCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockNATClientDeleteNATRuleCall) Do(f func(context.Context, string) error) *MockNATClientDeleteNATRuleCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockNATClientDeleteNATRuleCall) DoAndReturn(f func(context.Context, string) error) *MockNATClientDeleteNATRuleCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// FindNATRules mocks base method.
+func (m *MockNATClient) FindNATRules(ctx context.Context, filter mikrotik.NATRule) ([]mikrotik.NATRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindNATRules", ctx, filter)
+	ret0, _ := ret[0].([]mikrotik.NATRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindNATRules indicates an expected call of FindNATRules.
+func (mr *MockNATClientMockRecorder) FindNATRules(ctx, filter any) *MockNATClientFindNATRulesCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindNATRules", reflect.TypeOf((*MockNATClient)(nil).FindNATRules), ctx, filter)
+	return &MockNATClientFindNATRulesCall{Call: call}
+}
+
+// MockNATClientFindNATRulesCall wrap *gomock.Call
+type MockNATClientFindNATRulesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockNATClientFindNATRulesCall) Return(arg0 []mikrotik.NATRule, arg1 error) *MockNATClientFindNATRulesCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockNATClientFindNATRulesCall) Do(f func(context.Context, mikrotik.NATRule) ([]mikrotik.NATRule, error)) *MockNATClientFindNATRulesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockNATClientFindNATRulesCall) DoAndReturn(f func(context.Context, mikrotik.NATRule) ([]mikrotik.NATRule, error)) *MockNATClientFindNATRulesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// UpdateNATRule mocks base method.
+func (m *MockNATClient) UpdateNATRule(ctx context.Context, rule mikrotik.NATRule) (mikrotik.NATRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateNATRule", ctx, rule)
+	ret0, _ := ret[0].(mikrotik.NATRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateNATRule indicates an expected call of UpdateNATRule.
+func (mr *MockNATClientMockRecorder) UpdateNATRule(ctx, rule any) *MockNATClientUpdateNATRuleCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNATRule", reflect.TypeOf((*MockNATClient)(nil).UpdateNATRule), ctx, rule)
+	return &MockNATClientUpdateNATRuleCall{Call: call}
+}
+
+// MockNATClientUpdateNATRuleCall wrap *gomock.Call
+type MockNATClientUpdateNATRuleCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockNATClientUpdateNATRuleCall) Return(arg0 mikrotik.NATRule, arg1 error) *MockNATClientUpdateNATRuleCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockNATClientUpdateNATRuleCall) Do(f func(context.Context, mikrotik.NATRule) (mikrotik.NATRule, error)) *MockNATClientUpdateNATRuleCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockNATClientUpdateNATRuleCall) DoAndReturn(f func(context.Context, mikrotik.NATRule) (mikrotik.NATRule, error)) *MockNATClientUpdateNATRuleCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
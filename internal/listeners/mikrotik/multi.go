@@ -0,0 +1,202 @@
+package mikrotik
+
+import (
+	"cmp"
+	"context"
+	stderrors "errors"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/jfk9w/consul-publish/internal/consul"
+	"github.com/jfk9w/consul-publish/internal/listeners"
+	mtkapi "github.com/jfk9w/consul-publish/internal/mikrotik"
+)
+
+// Config holds the MikroTik configuration: the router configured inline and any number
+// of additional routers.
+type Config struct {
+	ListenerConfig `yaml:",inline"`
+	Routers        []Router `yaml:"routers,omitempty" doc:"Additional routers, each with its own credentials, records and node group; a failing router is logged without stopping the others"`
+}
+
+// Router configures an additional router. Routers have no defaults of their own: unset
+// connection settings, ttl and comment are taken from the top-level router, and the cluster
+// settings of the top-level router apply to all routers. The address list, NAT, lease and
+// safeguard settings are configured per router.
+type Router struct {
+	Name               string              `yaml:"name" doc:"Router name used in logs, metrics and errors"`
+	Host               string              `yaml:"host"`
+	User               string              `yaml:"user"`
+	Password           string              `yaml:"password"`
+	Scheme             string              `yaml:"scheme,omitempty" doc:"REST API scheme, http or https; the top-level scheme by default"`
+	CA                 string              `yaml:"ca,omitempty" doc:"Path to a PEM bundle with the CA certificates that sign the router certificate"`
+	Fingerprint        string              `yaml:"fingerprint,omitempty" doc:"Hex-encoded SHA-256 fingerprint of the router certificate; when set, only this certificate is accepted"`
+	InsecureSkipVerify bool                `yaml:"insecure-skip-verify,omitempty" doc:"Skip router certificate verification"`
+	Timeout            mtkapi.Duration     `yaml:"timeout,omitempty" doc:"Timeout of a single REST API request; the top-level timeout by default"`
+	Retries            *int                `yaml:"retries,omitempty" doc:"Number of retries of idempotent requests; the top-level retries by default"`
+	Backoff            mtkapi.Duration     `yaml:"backoff,omitempty" doc:"Delay before the first retry; the top-level backoff by default"`
+	TTL                mtkapi.Duration     `yaml:"ttl,omitempty" doc:"DNS record TTL; the top-level TTL by default"`
+	Comment            string              `yaml:"comment,omitempty" doc:"Comment used to tag records managed on this router; the top-level comment by default"`
+	Group              string              `yaml:"group,omitempty" doc:"Publish only the records of the nodes in this group; all nodes by default"`
+	CNAME              string              `yaml:"cname,omitempty" doc:"Domain suffix of node names; when set, domains are published as CNAME records to <node>.<suffix>, and every node gets an address record under the suffix"`
+	AddressLists       AddressLists        `yaml:"address-lists,omitempty" doc:"Firewall address list synchronisation settings"`
+	NAT                NAT                 `yaml:"nat,omitempty" doc:"Port forwarding settings"`
+	Leases             Leases              `yaml:"leases,omitempty" doc:"DHCP lease import settings; the source gets the router name as a suffix"`
+	Safeguard          listeners.Safeguard `yaml:"safeguard,omitempty" doc:"Protection against changes that delete most of the managed entries at once"`
+}
+
+// Listeners returns the configurations of all routers by name. The top-level router is named
+// "default" and is included only when its host is set.
+func (c Config) Listeners() (map[string]ListenerConfig, error) {
+	configs := make(map[string]ListenerConfig)
+	if c.Host != "" {
+		configs["default"] = c.ListenerConfig
+	}
+
+	for _, router := range c.Routers {
+		if _, ok := configs[router.Name]; ok || router.Name == "" {
+			return nil, errors.Errorf("router name %q is empty or not unique", router.Name)
+		}
+
+		configs[router.Name] = c.inherit(router)
+	}
+
+	return configs, nil
+}
+
+func (c Config) inherit(router Router) ListenerConfig {
+	cfg := ListenerConfig{
+		Config: mtkapi.Config{
+			Host:               router.Host,
+			User:               router.User,
+			Password:           router.Password,
+			Scheme:             cmp.Or(router.Scheme, c.Scheme),
+			CA:                 router.CA,
+			Fingerprint:        router.Fingerprint,
+			InsecureSkipVerify: router.InsecureSkipVerify,
+			Timeout:            cmp.Or(router.Timeout, c.Timeout),
+			Retries:            c.Retries,
+			Backoff:            cmp.Or(router.Backoff, c.Backoff),
+		},
+		TTL:          cmp.Or(router.TTL, c.TTL),
+		Comment:      cmp.Or(router.Comment, c.Comment),
+		Group:        router.Group,
+		CNAME:        router.CNAME,
+		Cluster:      c.Cluster,
+		AddressLists: router.AddressLists,
+		NAT:          router.NAT,
+		Leases:       router.Leases,
+		Safeguard:    router.Safeguard,
+		name:         router.Name,
+	}
+
+	if router.Retries != nil {
+		cfg.Retries = *router.Retries
+	}
+
+	// Every router needs its own lease source, as each importer deregisters the nodes of its source.
+	cfg.Leases.Source = cmp.Or(cfg.Leases.Source, c.Leases.Source) + "-" + router.Name
+	return cfg
+}
+
+// MultiListener reconciles several routers concurrently. A failure on one router
+// does not prevent the others from being reconciled.
+type MultiListener struct {
+	listeners map[string]*Listener
+}
+
+// NewMultiListener creates a Listener backed by a real MikroTik client for every configured router.
+func NewMultiListener(cfg Config) (*MultiListener, error) {
+	configs, err := cfg.Listeners()
+	if err != nil {
+		return nil, err
+	}
+
+	listeners := make(map[string]*Listener)
+	for name, cfg := range configs {
+		listener, err := NewListener(cfg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "router %s", name)
+		}
+
+		listeners[name] = listener
+	}
+
+	return NewMultiListenerWithListeners(listeners), nil
+}
+
+// NewMultiListenerWithListeners creates a MultiListener from listeners keyed by router name.
+// Intended for testing.
+func NewMultiListenerWithListeners(listeners map[string]*Listener) *MultiListener {
+	return &MultiListener{listeners: listeners}
+}
+
+// Name returns the listener name used in logs and metrics. Every router records its
+// notifications under its own name, mikrotik or mikrotik-<name>.
+func (m *MultiListener) Name() string {
+	return "mikrotik"
+}
+
+// MultiTarget marks the listener as recording the notifications of its routers.
+func (m *MultiListener) MultiTarget() {}
+
+func (m *MultiListener) KV() []string {
+	var prefixes []string
+	for _, name := range slices.Sorted(maps.Keys(m.listeners)) {
+		prefixes = append(prefixes, m.listeners[name].KV()...)
+	}
+
+	return prefixes
+}
+
+// Notify notifies the listeners of all routers concurrently. A router failure is logged and
+// does not prevent the other routers from being reconciled. The errors are returned only when
+// every router fails, so that one unreachable router does not stop the watcher.
+func (m *MultiListener) Notify(ctx context.Context, state *consul.State) error {
+	// Group resolves the groups lazily; resolve them before the state is shared between goroutines.
+	state.Group("all")
+
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+
+	m.each(func(name string, listener *Listener) {
+		start := time.Now()
+		err := listener.Notify(ctx, state)
+		consul.ObserveNotify(listener.cfg.label(), start, err)
+		if err != nil {
+			slog.Error("router reconciliation failed", "listener", "mikrotik", "router", name, "error", err)
+			mu.Lock()
+			errs = append(errs, errors.Wrapf(err, "router %s", name))
+			mu.Unlock()
+		}
+	})
+
+	if len(errs) < len(m.listeners) {
+		return nil
+	}
+
+	return stderrors.Join(errs...)
+}
+
+// SetLeader updates the leadership status of the listeners of all routers.
+func (m *MultiListener) SetLeader(ctx context.Context, leader bool) {
+	m.each(func(_ string, listener *Listener) {
+		listener.SetLeader(ctx, leader)
+	})
+}
+
+func (m *MultiListener) each(fn func(name string, listener *Listener)) {
+	var wg sync.WaitGroup
+	for name, listener := range m.listeners {
+		wg.Go(func() { fn(name, listener) })
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,170 @@
+package mikrotik_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jfk9w-go/confi"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jfk9w/consul-publish/internal/consul"
+	"github.com/jfk9w/consul-publish/internal/lib"
+	"github.com/jfk9w/consul-publish/internal/listeners/mikrotik"
+	mtkapi "github.com/jfk9w/consul-publish/internal/mikrotik"
+	"github.com/jfk9w/consul-publish/internal/mikrotik/mikrotiktest"
+)
+
+func router(name string, srv *mikrotiktest.Server, group string) mikrotik.Router {
+	cfg := srv.Config()
+	return mikrotik.Router{Name: name, Host: cfg.Host, User: cfg.User, Password: cfg.Password, Group: group}
+}
+
+// notifications returns the number of notifications of the router listener with the result.
+func notifications(t *testing.T, listener, result string) float64 {
+	t.Helper()
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(consul.Collectors()...)
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != "consul_publish_listener_notifications_total" {
+			continue
+		}
+
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+
+			if labels["listener"] == listener && labels["result"] == result {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+
+	return 0
+}
+
+func TestMultiListener_Notify(t *testing.T) {
+	home, vpn, broken := mikrotiktest.NewServer(), mikrotiktest.NewServer(), mikrotiktest.NewServer()
+	defer home.Close()
+	defer vpn.Close()
+	broken.Close()
+
+	l, err := mikrotik.NewMultiListener(mikrotik.Config{
+		ListenerConfig: mikrotik.ListenerConfig{
+			Config:  home.Config(),
+			TTL:     testTTL,
+			Comment: testComment,
+			Cluster: mikrotik.Cluster{Enabled: true},
+		},
+		Routers: []mikrotik.Router{
+			router("vpn", vpn, "site"),
+			router("broken", broken, ""),
+		},
+	})
+	require.NoError(t, err)
+	l.SetLeader(context.Background(), true)
+
+	state := &consul.State{
+		Self: "node1",
+		Nodes: map[string]consul.Node{
+			"node1": {Name: "node1", Address: "10.0.0.1", Services: []consul.Service{service("home.local")}},
+			"node2": {Name: "node2", Address: "10.0.1.1", Groups: lib.SetOf("site"), Services: []consul.Service{service("site.local")}},
+		},
+	}
+
+	vpnBefore, brokenBefore := notifications(t, "mikrotik-vpn", "success"), notifications(t, "mikrotik-broken", "failure")
+
+	// The broken router is only logged, as the other routers are reconciled.
+	require.NoError(t, l.Notify(context.Background(), state))
+
+	// Every router records its notifications under its own name.
+	require.Equal(t, 1.0, notifications(t, "mikrotik-vpn", "success")-vpnBefore)
+	require.Equal(t, 1.0, notifications(t, "mikrotik-broken", "failure")-brokenBefore)
+
+	require.Equal(t, []mtkapi.DNSRecord{
+		{ID: "*1", Name: "home.local", Address: "10.0.0.1", TTL: testTTL, Comment: testComment},
+		{ID: "*2", Name: "site.local", Address: "10.0.1.1", TTL: testTTL, Comment: testComment},
+	}, home.DNSRecords())
+
+	// The vpn router publishes only the site group, with the TTL and comment of the top-level router.
+	require.Equal(t, []mtkapi.DNSRecord{
+		{ID: "*1", Name: "site.local", Address: "10.0.1.1", TTL: testTTL, Comment: testComment},
+	}, vpn.DNSRecords())
+}
+
+func TestMultiListener_Notify_AllRoutersFail(t *testing.T) {
+	home, vpn := mikrotiktest.NewServer(), mikrotiktest.NewServer()
+	home.Close()
+	vpn.Close()
+
+	l, err := mikrotik.NewMultiListener(mikrotik.Config{
+		ListenerConfig: mikrotik.ListenerConfig{Config: home.Config(), TTL: testTTL, Comment: testComment},
+		Routers:        []mikrotik.Router{router("vpn", vpn, "")},
+	})
+	require.NoError(t, err)
+
+	state := &consul.State{
+		Self:  "node1",
+		Nodes: map[string]consul.Node{"node1": {Name: "node1", Address: "10.0.0.1", Services: []consul.Service{service("home.local")}}},
+	}
+
+	err = l.Notify(context.Background(), state)
+	require.ErrorContains(t, err, "router default")
+	require.ErrorContains(t, err, "router vpn")
+}
+
+func TestConfig_Listeners_Defaults(t *testing.T) {
+	var cfg mikrotik.Config
+	require.NoError(t, yaml.Unmarshal([]byte(`
+host: 192.168.88.1
+ttl: 1h
+comment: home
+retries: 5
+leases:
+  enabled: true
+routers:
+  - name: vpn
+    host: 10.8.0.1
+    leases:
+      enabled: true
+  - name: office
+    host: 10.9.0.1
+    retries: 0
+    comment: office
+    leases:
+      enabled: true
+      source: office-dhcp
+`), &cfg))
+
+	schema, err := confi.GenerateSchema(cfg)
+	require.NoError(t, err)
+	require.NoError(t, schema.ApplyDefaults(&cfg))
+
+	configs, err := cfg.Listeners()
+	require.NoError(t, err)
+
+	top, vpn, office := configs["default"], configs["vpn"], configs["office"]
+	require.Equal(t, mtkapi.Duration(time.Hour), vpn.TTL)
+	require.Equal(t, "home", vpn.Comment)
+	require.Equal(t, "http", vpn.Scheme)
+	require.Equal(t, 5, vpn.Retries)
+	require.Equal(t, top.Timeout, vpn.Timeout)
+	require.Equal(t, "office", office.Comment)
+	require.Equal(t, 0, office.Retries)
+
+	// Every lease importer needs its own source, as it deregisters the other nodes of the source.
+	require.Equal(t, "mikrotik-dhcp", top.Leases.Source)
+	require.Equal(t, "mikrotik-dhcp-vpn", vpn.Leases.Source)
+	require.Equal(t, "office-dhcp-office", office.Leases.Source)
+}
+
+func TestConfig_Listeners_DuplicateName(t *testing.T) {
+	_, err := mikrotik.Config{Routers: []mikrotik.Router{{Name: "a"}, {Name: "a"}}}.Listeners()
+	require.Error(t, err)
+}
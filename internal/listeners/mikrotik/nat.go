@@ -0,0 +1,223 @@
+package mikrotik
+
+import (
+	"context"
+	"log/slog"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/jfk9w/consul-publish/internal/consul"
+	"github.com/jfk9w/consul-publish/internal/listeners"
+	mtkapi "github.com/jfk9w/consul-publish/internal/mikrotik"
+)
+
+// NAT configures dst-nat port forwards from the publish-wan service metadata key.
+type NAT struct {
+	Enabled         bool     `yaml:"enabled,omitempty" doc:"Reconcile dst-nat rules from the publish-wan service metadata"`
+	InInterfaceList string   `yaml:"in-interface-list,omitempty" default:"WAN" doc:"Interface list matched by the managed dst-nat rules"`
+	Allow           []string `yaml:"allow,omitempty" doc:"External ports which may be forwarded, as <protocol>:<port> or <protocol>:<from>-<to>; other forwards are ignored"`
+}
+
+// portForward is a single publish-wan entry.
+type portForward struct {
+	protocol string
+	external int
+	internal int
+}
+
+// parsePortForward parses a publish-wan entry in the form <protocol>:<external>-><internal>,
+// or <protocol>:<port> when both ports are the same.
+func parsePortForward(value string) (portForward, error) {
+	protocol, ports, ok := strings.Cut(value, ":")
+	if !ok || (protocol != "tcp" && protocol != "udp") {
+		return portForward{}, errors.Errorf("expected tcp:<port> or udp:<port>, got %q", value)
+	}
+
+	external, internal, ok := strings.Cut(ports, "->")
+	if !ok {
+		internal = external
+	}
+
+	var (
+		forward = portForward{protocol: protocol}
+		err     error
+	)
+
+	if forward.external, err = parsePort(external); err != nil {
+		return portForward{}, err
+	}
+
+	if forward.internal, err = parsePort(internal); err != nil {
+		return portForward{}, err
+	}
+
+	return forward, nil
+}
+
+func parsePort(value string) (int, error) {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, errors.Errorf("invalid port %q", value)
+	}
+
+	return port, nil
+}
+
+// portRange is an allowlist entry: the external ports from low to high of a protocol.
+type portRange struct {
+	protocol  string
+	low, high int
+}
+
+// parsePortRange parses an allowlist entry in the form <protocol>:<port> or <protocol>:<from>-<to>.
+func parsePortRange(value string) (portRange, error) {
+	protocol, ports, ok := strings.Cut(value, ":")
+	if !ok || (protocol != "tcp" && protocol != "udp") {
+		return portRange{}, errors.Errorf("expected tcp:<ports> or udp:<ports>, got %q", value)
+	}
+
+	from, to, ok := strings.Cut(ports, "-")
+	if !ok {
+		to = from
+	}
+
+	var (
+		allowed = portRange{protocol: protocol}
+		err     error
+	)
+
+	if allowed.low, err = parsePort(from); err != nil {
+		return portRange{}, err
+	}
+
+	if allowed.high, err = parsePort(to); err != nil {
+		return portRange{}, err
+	}
+
+	if allowed.low > allowed.high {
+		return portRange{}, errors.Errorf("invalid port range %q", ports)
+	}
+
+	return allowed, nil
+}
+
+// validate checks the allowlist, so that a typo is reported when the listener is created
+// rather than on every update.
+func (n NAT) validate() error {
+	if !n.Enabled {
+		return nil
+	}
+
+	for _, entry := range n.Allow {
+		if _, err := parsePortRange(entry); err != nil {
+			return errors.Wrap(err, "invalid NAT allowlist entry")
+		}
+	}
+
+	return nil
+}
+
+// allowed reports whether the external port of forward is in the configured allowlist.
+func (n NAT) allowed(forward portForward) bool {
+	for _, entry := range n.Allow {
+		allowed, err := parsePortRange(entry)
+		if err == nil && allowed.protocol == forward.protocol && allowed.low <= forward.external && forward.external <= allowed.high {
+			return true
+		}
+	}
+
+	return false
+}
+
+// desiredRules returns the desired dst-nat rules keyed by ruleKey.
+// Every allowed publish-wan entry forwards the external port to the service address,
+// falling back to the address of its node. If several services claim the same external port,
+// the first one wins.
+func (l *Listener) desiredRules(state *consul.State) map[string]mtkapi.NATRule {
+	desired := make(map[string]mtkapi.NATRule)
+	for _, node := range l.nodes(state) {
+		for _, service := range node.Services {
+			address := service.Address
+			if address == "" {
+				address = node.Address
+			}
+
+			for _, value := range strings.Fields(service.Meta[listeners.PublishWANKey]) {
+				log := slog.With("listener", "mikrotik", "node", node.Name, "service", service.ID, "forward", value)
+				forward, err := parsePortForward(value)
+				if err != nil {
+					log.Warn("invalid port forward", "error", err)
+					continue
+				}
+
+				if !l.cfg.NAT.allowed(forward) {
+					log.Warn("port forward is not allowed")
+					continue
+				}
+
+				if addr, err := netip.ParseAddr(address); err != nil || !addr.Is4() {
+					log.Warn("port forward requires an IPv4 address", "address", address)
+					continue
+				}
+
+				rule := mtkapi.NATRule{
+					Chain:           "dstnat",
+					Action:          "dst-nat",
+					Protocol:        forward.protocol,
+					DstPort:         strconv.Itoa(forward.external),
+					InInterfaceList: l.cfg.NAT.InInterfaceList,
+					ToAddresses:     address,
+					ToPorts:         strconv.Itoa(forward.internal),
+				}
+
+				key := ruleKey(rule)
+				if _, ok := desired[key]; ok {
+					log.Warn("external port is already forwarded")
+					continue
+				}
+
+				desired[key] = rule
+			}
+		}
+	}
+
+	return desired
+}
+
+// natRules returns the reconciler of the NAT rules tagged with the configured comment.
+func (l *Listener) natRules(check *listeners.Check) reconciler[mtkapi.NATRule] {
+	return reconciler[mtkapi.NATRule]{
+		kind: "NAT rule",
+		key:  ruleKey,
+		name: func(rule mtkapi.NATRule) string {
+			return rule.Protocol + ":" + rule.DstPort
+		},
+		equal: func(desired, existing mtkapi.NATRule) bool {
+			existing.ID, existing.Comment = "", ""
+			return desired == existing
+		},
+		id: func(rule mtkapi.NATRule) string { return rule.ID },
+		find: func(ctx context.Context) ([]mtkapi.NATRule, error) {
+			return l.client.FindNATRules(ctx, mtkapi.NATRule{Comment: l.cfg.Comment})
+		},
+		create: func(ctx context.Context, rule mtkapi.NATRule) error {
+			rule.Comment = l.cfg.Comment
+			_, err := l.client.CreateNATRule(ctx, rule)
+			return err
+		},
+		update: func(ctx context.Context, id string, rule mtkapi.NATRule) error {
+			rule.ID, rule.Comment = id, l.cfg.Comment
+			_, err := l.client.UpdateNATRule(ctx, rule)
+			return err
+		},
+		allow:  check.Allow,
+		delete: l.client.DeleteNATRule,
+	}
+}
+
+func ruleKey(rule mtkapi.NATRule) string {
+	return rule.Protocol + "\x00" + rule.DstPort
+}
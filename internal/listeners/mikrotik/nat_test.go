@@ -0,0 +1,74 @@
+package mikrotik_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jfk9w/consul-publish/internal/consul"
+	"github.com/jfk9w/consul-publish/internal/listeners/mikrotik"
+	mtkapi "github.com/jfk9w/consul-publish/internal/mikrotik"
+	"github.com/jfk9w/consul-publish/internal/mikrotik/mikrotiktest"
+)
+
+func natRuleOf(id, protocol, dstPort, toAddresses, toPorts string) mtkapi.NATRule {
+	return mtkapi.NATRule{
+		ID:              id,
+		Chain:           "dstnat",
+		Action:          "dst-nat",
+		Protocol:        protocol,
+		DstPort:         dstPort,
+		InInterfaceList: "WAN",
+		ToAddresses:     toAddresses,
+		ToPorts:         toPorts,
+		Comment:         testComment,
+	}
+}
+
+func TestListener_Notify_NATRules(t *testing.T) {
+	srv := mikrotiktest.NewServer()
+	defer srv.Close()
+
+	srv.Add(mikrotiktest.NAT, natRuleOf("", "tcp", "443", "10.0.0.9", "443"))
+	srv.Add(mikrotiktest.NAT, natRuleOf("", "tcp", "22", "10.0.0.1", "22"))
+	srv.Add(mikrotiktest.NAT, mtkapi.NATRule{Chain: "srcnat", Action: "masquerade"})
+
+	l, err := mikrotik.NewListener(mikrotik.ListenerConfig{
+		Config:  srv.Config(),
+		TTL:     testTTL,
+		Comment: testComment,
+		NAT: mikrotik.NAT{
+			Enabled:         true,
+			InInterfaceList: "WAN",
+			Allow:           []string{"tcp:443", "udp:51820-51829"},
+		},
+	})
+	require.NoError(t, err)
+
+	state := stateWithServices("10.0.0.1",
+		consul.Service{ID: "web", Meta: map[string]string{"publish-wan": "tcp:443->8443 tcp:22"}},
+		consul.Service{ID: "vpn", Address: "10.0.0.5", Meta: map[string]string{"publish-wan": "udp:51820 udp:bad"}},
+		consul.Service{ID: "other", Meta: map[string]string{"publish-wan": "tcp:443"}},
+	)
+
+	for range 2 {
+		require.NoError(t, l.Notify(context.Background(), state))
+		require.Equal(t, []mtkapi.NATRule{
+			natRuleOf("*1", "tcp", "443", "10.0.0.1", "8443"),
+			{ID: "*3", Chain: "srcnat", Action: "masquerade"},
+			natRuleOf("*4", "udp", "51820", "10.0.0.5", "51820"),
+		}, srv.NATRules())
+	}
+
+	// Stale rules are deleted when the services go away.
+	require.NoError(t, l.Notify(context.Background(), stateWithServices("10.0.0.1")))
+	require.Equal(t, []mtkapi.NATRule{{ID: "*3", Chain: "srcnat", Action: "masquerade"}}, srv.NATRules())
+}
+
+func TestNewListener_InvalidNATAllow(t *testing.T) {
+	for _, entry := range []string{"tpc:80", "tcp:http", "udp:51829-51820", "tcp:0"} {
+		_, err := mikrotik.NewListener(mikrotik.ListenerConfig{NAT: mikrotik.NAT{Enabled: true, Allow: []string{"tcp:443", entry}}})
+		require.ErrorContains(t, err, "invalid NAT allowlist entry", entry)
+	}
+}
@@ -0,0 +1,111 @@
+package mikrotik
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+// reconciler brings the RouterOS entries owned by the listener into the desired state.
+// Entries are matched by key; for every desired key exactly one entry with equal data is
+// kept, duplicates are deleted, and entries with other keys are removed. Keys are processed
+// in sorted order, so that the router sees the same sequence of changes for the same state.
+type reconciler[T any] struct {
+	kind   string                                 // entry kind used in logs and errors
+	key    func(entry T) string                   // identity of an entry
+	name   func(entry T) string                   // human-readable entry name
+	equal  func(desired, existing T) bool         // reports whether existing needs no update
+	id     func(entry T) string                   // RouterOS .id of an existing entry
+	find   func(ctx context.Context) ([]T, error) // lists the entries owned by the listener
+	create func(ctx context.Context, entry T) error
+	update func(ctx context.Context, id string, entry T) error
+	delete func(ctx context.Context, id string) error
+	allow  func(kind string, total, removed int) bool // safeguard against mass deletions
+}
+
+func (r reconciler[T]) reconcile(ctx context.Context, desired map[string]T) error {
+	entries, err := r.find(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "fetch existing %ss", r.kind)
+	}
+
+	existing := make(map[string][]T, len(entries))
+	for _, entry := range entries {
+		key := r.key(entry)
+		existing[key] = append(existing[key], entry)
+	}
+
+	removed := 0
+	for key, entries := range existing {
+		if _, ok := desired[key]; !ok {
+			removed += len(entries)
+		}
+	}
+
+	if !r.allow(r.kind, len(entries), removed) {
+		return nil
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(desired)) {
+		entry := desired[key]
+		if err := r.reconcileEntry(ctx, entry, existing[key]); err != nil {
+			return errors.Wrapf(err, "reconcile %s %s", r.kind, r.name(entry))
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(existing)) {
+		if _, ok := desired[key]; ok {
+			continue
+		}
+		for _, entry := range existing[key] {
+			slog.Info("deleting "+r.kind, "name", r.name(entry), "id", r.id(entry))
+			if err := r.delete(ctx, r.id(entry)); err != nil {
+				return errors.Wrapf(err, "delete %s %s (id=%s)", r.kind, r.name(entry), r.id(entry))
+			}
+		}
+	}
+
+	return nil
+}
+
+// reconcileEntry brings the entries sharing the key of desired into the desired state:
+// exactly one entry with the desired data. If multiple entries exist, duplicates are deleted;
+// if the data is wrong, the kept entry is updated in-place.
+func (r reconciler[T]) reconcileEntry(ctx context.Context, desired T, existing []T) error {
+	name := r.name(desired)
+	matchIdx := -1
+	for i, entry := range existing {
+		if r.equal(desired, entry) {
+			matchIdx = i
+			break
+		}
+	}
+
+	keepIdx := max(matchIdx, 0)
+	for i, entry := range existing {
+		if i == keepIdx {
+			continue
+		}
+		slog.Info("deleting duplicate "+r.kind, "name", name, "id", r.id(entry))
+		if err := r.delete(ctx, r.id(entry)); err != nil {
+			return errors.Wrapf(err, "delete duplicate id=%s", r.id(entry))
+		}
+	}
+
+	switch {
+	case len(existing) == 0:
+		slog.Info("creating "+r.kind, "name", name)
+		return errors.Wrapf(r.create(ctx, desired), "create %s", r.kind)
+
+	case matchIdx < 0:
+		id := r.id(existing[keepIdx])
+		slog.Info("updating "+r.kind, "name", name, "id", id)
+		return errors.Wrapf(r.update(ctx, id, desired), "update %s", r.kind)
+
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,152 @@
+package mikrotik_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jfk9w/consul-publish/internal/consul"
+	"github.com/jfk9w/consul-publish/internal/lib"
+	"github.com/jfk9w/consul-publish/internal/listeners"
+	"github.com/jfk9w/consul-publish/internal/listeners/mikrotik"
+	mtkapi "github.com/jfk9w/consul-publish/internal/mikrotik"
+	"github.com/jfk9w/consul-publish/internal/mikrotik/mikrotiktest"
+)
+
+func TestListener_Notify_FinalState(t *testing.T) {
+	srv := mikrotiktest.NewServer()
+	defer srv.Close()
+
+	srv.Add(mikrotiktest.DNSStatic, mtkapi.DNSRecord{Name: "router.lan", Address: "10.0.0.254"})
+	srv.Add(mikrotiktest.DNSStatic, recordOf("", "stale.local", "10.0.0.1"))
+	srv.Add(mikrotiktest.DNSStatic, recordOf("", "svc.local", "10.0.0.9"))
+	srv.Add(mikrotiktest.DNSStatic, recordOf("", "svc.local", "10.0.0.1"))
+	srv.Add(mikrotiktest.AddressList, entryOf("", "consul-servers", "10.0.0.9"))
+
+	l, err := mikrotik.NewListener(mikrotik.ListenerConfig{
+		Config:  srv.Config(),
+		TTL:     testTTL,
+		Comment: testComment,
+		AddressLists: mikrotik.AddressLists{
+			Enabled: true,
+			Groups:  []string{"servers"},
+			Prefix:  "consul-",
+		},
+	})
+	require.NoError(t, err)
+
+	state := stateWithServices("10.0.0.1", service("svc.local"), service("*.apps.local"))
+	node := state.Nodes["node1"]
+	node.Groups = lib.SetOf("servers")
+	state.Nodes["node1"] = node
+
+	// The second notification must leave the router state unchanged.
+	for range 2 {
+		require.NoError(t, l.Notify(context.Background(), state))
+
+		require.Equal(t, []mtkapi.DNSRecord{
+			{ID: "*1", Name: "router.lan", Address: "10.0.0.254"},
+			{ID: "*4", Name: "svc.local", Address: "10.0.0.1", Comment: testComment},
+			{ID: "*6", Regexp: `^.+\.apps\.local$`, Address: "10.0.0.1", TTL: testTTL, Comment: testComment},
+		}, srv.DNSRecords())
+
+		require.Equal(t, []mtkapi.AddressListEntry{
+			entryOf("*7", "consul-servers", "10.0.0.1"),
+		}, srv.AddressListEntries())
+	}
+}
+
+func TestListener_Notify_MetadataOverrides(t *testing.T) {
+	srv := mikrotiktest.NewServer()
+	defer srv.Close()
+
+	// The record reported with another TTL is updated.
+	srv.Add(mikrotiktest.DNSStatic, mtkapi.DNSRecord{Name: "node.local", Address: "10.0.0.1", TTL: testTTL, Comment: testComment})
+
+	l, err := mikrotik.NewListener(mikrotik.ListenerConfig{Config: srv.Config(), TTL: testTTL, Comment: testComment})
+	require.NoError(t, err)
+
+	state := stateWithServices("10.0.0.1",
+		consul.Service{
+			ID:              "web",
+			TaggedAddresses: map[string]string{"wan": "203.0.113.10"},
+			Meta:            map[string]string{"domain-name": "web.example.com", "mikrotik-address": "wan", "mikrotik-ttl": "1h"},
+		},
+		consul.Service{ID: "db", Meta: map[string]string{"domain-name": "db.local", "mikrotik-address": "lan"}},
+		consul.Service{ID: "v6", Meta: map[string]string{"domain-name": "v6.local", "mikrotik-address": "fd00::10"}},
+		consul.Service{ID: "off", Meta: map[string]string{"domain-name": "off.local", "mikrotik-disabled": "true"}},
+	)
+
+	node := state.Nodes["node1"]
+	node.TaggedAddresses = map[string]string{"lan": "192.168.1.10"}
+	node.Meta = map[string]string{"domain-name": "node.local", "mikrotik-ttl": "1m"}
+	state.Nodes["node1"] = node
+
+	require.NoError(t, l.Notify(context.Background(), state))
+	require.Equal(t, []mtkapi.DNSRecord{
+		{ID: "*1", Name: "node.local", Address: "10.0.0.1", TTL: mtkapi.Duration(time.Minute), Comment: testComment},
+		{ID: "*2", Name: "db.local", Address: "192.168.1.10", TTL: testTTL, Comment: testComment},
+		{ID: "*3", Name: "web.example.com", Address: "203.0.113.10", TTL: mtkapi.Duration(time.Hour), Comment: testComment},
+		{ID: "*4", Name: "v6.local", Type: mtkapi.RecordAAAA, Address: "fd00::10", TTL: testTTL, Comment: testComment},
+	}, srv.DNSRecords())
+}
+
+func TestListener_Notify_SafeguardBlocksMassDeletion(t *testing.T) {
+	srv := mikrotiktest.NewServer()
+	defer srv.Close()
+
+	for _, name := range []string{"a.local", "b.local", "c.local"} {
+		srv.Add(mikrotiktest.DNSStatic, recordOf("", name, "10.0.0.1"))
+	}
+
+	l, err := mikrotik.NewListener(mikrotik.ListenerConfig{
+		Config:    srv.Config(),
+		TTL:       testTTL,
+		Comment:   testComment,
+		Safeguard: listeners.Safeguard{MaxDeletes: 2, Confirm: "confirm"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"confirm"}, l.KV())
+
+	state := stateWithServices("10.0.0.1")
+	state.KV = consul.Folder{}
+	require.NoError(t, l.Notify(context.Background(), state))
+	require.Len(t, srv.DNSRecords(), 3)
+
+	state.KV = consul.Folder{"confirm": consul.Folder{"mikrotik": consul.Value("yes")}}
+	require.NoError(t, l.Notify(context.Background(), state))
+	require.Empty(t, srv.DNSRecords())
+}
+
+// TestListener_SetLeader_SafeguardNotConfirmedByFollowerChange checks that a confirmation
+// written while the node was a follower does not confirm its first reconciliation as leader.
+func TestListener_SetLeader_SafeguardNotConfirmedByFollowerChange(t *testing.T) {
+	srv := mikrotiktest.NewServer()
+	defer srv.Close()
+
+	l, err := mikrotik.NewListener(mikrotik.ListenerConfig{
+		Config:    srv.Config(),
+		TTL:       testTTL,
+		Comment:   testComment,
+		Cluster:   mikrotik.Cluster{Enabled: true},
+		Safeguard: listeners.Safeguard{MaxDeletes: 2, Confirm: "confirm"},
+	})
+	require.NoError(t, err)
+
+	l.SetLeader(context.Background(), true)
+	state := stateWithServices("10.0.0.1", service("a.local"), service("b.local"), service("c.local"))
+	state.KV = consul.Folder{"confirm": consul.Folder{"mikrotik": consul.Value("1")}}
+	require.NoError(t, l.Notify(context.Background(), state))
+	require.Len(t, srv.DNSRecords(), 3)
+
+	// The services disappear and the key changes while another node is the leader.
+	l.SetLeader(context.Background(), false)
+	state = stateWithServices("10.0.0.1")
+	state.KV = consul.Folder{"confirm": consul.Folder{"mikrotik": consul.Value("2")}}
+	require.NoError(t, l.Notify(context.Background(), state))
+
+	l.SetLeader(context.Background(), true)
+	require.Len(t, srv.DNSRecords(), 3)
+}
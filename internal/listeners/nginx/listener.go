@@ -0,0 +1,228 @@
+// Package nginx generates nginx upstream and server blocks from Consul services.
+package nginx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/jfk9w/consul-publish/internal/consul"
+	. "github.com/jfk9w/consul-publish/internal/listeners"
+)
+
+var invalidChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+const defaultLocation = `location / {
+    proxy_pass http://[[ Upstream ]];
+    proxy_set_header Host $host;
+    proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;
+    proxy_set_header X-Forwarded-Proto $scheme;
+}`
+
+// Config holds the nginx listener settings.
+type Config struct {
+	KV     string `yaml:"kv,omitempty" doc:"Consul KV prefix that holds optional per-service location templates"`
+	File   File   `yaml:"file" doc:"Generated nginx configuration file settings"`
+	Listen string `yaml:"listen,omitempty" default:"80" doc:"Value of the listen directive in generated server blocks"`
+	Common string `yaml:"common,omitempty" doc:"Common nginx directives added to every generated server block"`
+	Check  string `yaml:"check,omitempty" default:"nginx -t" doc:"Command that validates the written configuration; on failure the previous file is restored"`
+	Exec   string `yaml:"exec" doc:"Command to run after the nginx configuration changes"`
+}
+
+// Listener writes nginx upstream and server blocks for HTTP-published services.
+type Listener struct {
+	cfg Config
+}
+
+// New creates a Listener with the given configuration.
+func New(cfg Config) *Listener {
+	return &Listener{cfg: cfg}
+}
+
+// Name returns the listener name used in logs and metrics.
+func (l *Listener) Name() string {
+	return "nginx"
+}
+
+func (l *Listener) KV() []string {
+	if l.cfg.KV == "" {
+		return nil
+	}
+
+	return []string{l.cfg.KV}
+}
+
+// Notify regenerates the nginx configuration, validates it with the check command
+// and runs the reload command when the file changed.
+func (l *Listener) Notify(ctx context.Context, state *consul.State) error {
+	var definitions map[string]consul.Value
+	if l.cfg.KV != "" {
+		folder, ok := state.KV.Get(l.cfg.KV).(consul.Folder)
+		if !ok {
+			return errors.Errorf("%s is not a folder", l.cfg.KV)
+		}
+
+		definitions = maps.Collect(folder.Values())
+	}
+
+	log := slog.With("listener", l.Name(), "self", state.Self)
+	changed, err := l.cfg.File.WriteChecked(
+		func(file io.Writer) error { return l.write(state, file, definitions) },
+		func() error {
+			if l.cfg.Check == "" {
+				return nil
+			}
+
+			return Exec(ctx, l.Name(), l.cfg.Check)
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "write nginx configuration")
+	}
+
+	log.Debug("rendered nginx configuration", "changed", changed)
+	if changed && l.cfg.Exec != "" {
+		log.Info("nginx configuration changed, reloading")
+		if err := Exec(ctx, l.Name(), l.cfg.Exec); err != nil {
+			log.Error("failed to reload nginx", "error", err)
+			return errors.Wrap(err, "reload nginx")
+		}
+		log.Info("nginx reloaded")
+	}
+
+	return nil
+}
+
+func (l *Listener) write(state *consul.State, file io.Writer, definitions map[string]consul.Value) error {
+	services := GetInstances(state)
+	upstreams := make(map[string][]Instance)
+	servers := make(map[string][]string)
+	for _, id := range slices.Sorted(maps.Keys(services)) {
+		var instances []Instance
+		for _, instance := range services[id] {
+			if instance.Service.Port == 0 {
+				continue
+			}
+
+			if len(GetHTTPDomainNames(state, instance.Service.Meta)) == 0 {
+				continue
+			}
+
+			instances = append(instances, instance)
+		}
+
+		if len(instances) == 0 {
+			continue
+		}
+
+		domain, _ := GetDomainName(instances[0].Service.Meta)
+		servers[domain] = append(servers[domain], id)
+		upstreams[id] = instances
+	}
+
+	blocks := 0
+	separate := func() error {
+		blocks++
+		if blocks == 1 {
+			return nil
+		}
+
+		_, err := fmt.Fprintln(file)
+		return err
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(upstreams)) {
+		if err := separate(); err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(file, "upstream %s {\n", upstreamName(id)); err != nil {
+			return errors.Wrapf(err, "write upstream for %s", id)
+		}
+
+		for _, instance := range upstreams[id] {
+			if _, err := fmt.Fprintf(file, "    server %s:%d;\n", instance.Service.Address, instance.Service.Port); err != nil {
+				return err
+			}
+		}
+
+		if _, err := fmt.Fprintln(file, "}"); err != nil {
+			return err
+		}
+	}
+
+	for _, domain := range slices.Sorted(maps.Keys(servers)) {
+		if err := separate(); err != nil {
+			return err
+		}
+
+		ids := servers[domain]
+		names := GetHostNames(upstreams[ids[0]][0].Service.Meta)
+		if _, err := fmt.Fprintf(file, "server {\n    listen %s;\n    server_name %s;\n", l.cfg.Listen, strings.Join(names, " ")); err != nil {
+			return errors.Wrapf(err, "write start for %s", domain)
+		}
+
+		if err := WriteIndented(file, l.cfg.Common); err != nil {
+			return errors.Wrapf(err, "write common block for %s", domain)
+		}
+
+		for _, id := range ids {
+			tmpl, err := l.tmpl(id, definitions)
+			if err != nil {
+				return err
+			}
+
+			if _, err := fmt.Fprintln(file); err != nil {
+				return err
+			}
+
+			if err := tmpl.Execute(file, upstreams[id]); err != nil {
+				return errors.Wrapf(err, "execute template for %s", id)
+			}
+
+			if _, err := fmt.Fprintln(file); err != nil {
+				return err
+			}
+		}
+
+		if _, err := fmt.Fprintln(file, "}"); err != nil {
+			return errors.Wrapf(err, "write end for %s", domain)
+		}
+	}
+
+	return nil
+}
+
+// tmpl returns the location template for the service: the KV definition when present,
+// or a plain proxy_pass to the service upstream otherwise.
+func (l *Listener) tmpl(id string, definitions map[string]consul.Value) (*template.Template, error) {
+	definition, ok := definitions[id]
+	if !ok {
+		definition = consul.Value(defaultLocation)
+	}
+
+	funcs := template.FuncMap{
+		"Upstream": func() string { return upstreamName(id) },
+	}
+
+	text := strings.Trim(string(definition), " \n\t\v")
+	text = Indent(text)
+	tmpl, err := template.New(id).Delims("[[", "]]").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse template for %s", id)
+	}
+
+	return tmpl, nil
+}
+
+func upstreamName(id string) string {
+	return invalidChars.ReplaceAllString(id, "_")
+}
@@ -0,0 +1,187 @@
+package nginx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jfk9w/consul-publish/internal/consul"
+	"github.com/jfk9w/consul-publish/internal/listeners"
+	"github.com/jfk9w/consul-publish/internal/listeners/listenerstest"
+)
+
+func testState() *consul.State {
+	return &consul.State{
+		Self: "proxy",
+		Nodes: map[string]consul.Node{
+			"proxy": {
+				Name: "proxy", Address: "10.0.0.1",
+				Services: []consul.Service{{
+					ID: "grafana", Address: "10.0.0.1", Port: 3000,
+					Meta: map[string]string{
+						listeners.DomainNameKey:  "https://grafana.example.com",
+						listeners.PublishHTTPKey: "all",
+					},
+				}},
+			},
+			"backend": {
+				Name: "backend", Address: "10.0.0.2",
+				Services: []consul.Service{
+					{
+						ID: "grafana", Address: "10.0.0.2", Port: 3000,
+						Meta: map[string]string{
+							listeners.DomainNameKey:  "https://grafana.example.com",
+							listeners.PublishHTTPKey: "all",
+						},
+					},
+					{
+						ID: "api", Address: "10.0.0.2", Port: 8080,
+						Meta: map[string]string{
+							listeners.DomainNameKey:  "api.example.com:8443 www.example.com",
+							listeners.PublishHTTPKey: "all",
+						},
+					},
+					{
+						ID: "hidden", Address: "10.0.0.2", Port: 8081,
+						Meta: map[string]string{
+							listeners.DomainNameKey:  "hidden.example.com",
+							listeners.PublishHTTPKey: "other",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	definitions := map[string]consul.Value{
+		"api": []byte("location /api/ {\n    proxy_pass http://[[ Upstream ]]/;\n}"),
+	}
+
+	var got bytes.Buffer
+	if err := New(Config{Listen: "80", Common: "access_log off;"}).write(testState(), &got, definitions); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+
+	want := "upstream api {\n" +
+		"    server 10.0.0.2:8080;\n" +
+		"}\n" +
+		"\n" +
+		"upstream grafana {\n" +
+		"    server 10.0.0.2:3000;\n" +
+		"    server 127.0.0.1:3000;\n" +
+		"}\n" +
+		"\n" +
+		"server {\n" +
+		"    listen 80;\n" +
+		"    server_name api.example.com www.example.com;\n" +
+		"    access_log off;\n" +
+		"\n" +
+		"    location /api/ {\n" +
+		"        proxy_pass http://api/;\n" +
+		"    }\n" +
+		"}\n" +
+		"\n" +
+		"server {\n" +
+		"    listen 80;\n" +
+		"    server_name grafana.example.com;\n" +
+		"    access_log off;\n" +
+		"\n" +
+		"    location / {\n" +
+		"        proxy_pass http://grafana;\n" +
+		"        proxy_set_header Host $host;\n" +
+		"        proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;\n" +
+		"        proxy_set_header X-Forwarded-Proto $scheme;\n" +
+		"    }\n" +
+		"}\n"
+	if got.String() != want {
+		t.Errorf("write() = %q, want %q", got.String(), want)
+	}
+}
+
+func TestUpstreamName(t *testing.T) {
+	t.Parallel()
+
+	if got := upstreamName("app:v2/web"); got != "app_v2_web" {
+		t.Errorf("upstreamName() = %q, want %q", got, "app_v2_web")
+	}
+}
+
+func TestWriteTemplateError(t *testing.T) {
+	t.Parallel()
+
+	err := New(Config{}).write(testState(), &bytes.Buffer{}, map[string]consul.Value{"api": []byte("[[")})
+	if err == nil || !strings.Contains(err.Error(), "parse template for api") {
+		t.Fatalf("write() error = %v, want template parse error", err)
+	}
+}
+
+func TestNotifyRestoresFileWhenCheckFails(t *testing.T) {
+	t.Parallel()
+
+	file := listenerstest.File(t, "consul.conf")
+	if err := os.WriteFile(file.Path, []byte("# previous\n"), 0o644); err != nil {
+		t.Fatalf("write previous configuration: %v", err)
+	}
+
+	marker := filepath.Join(filepath.Dir(file.Path), "reloaded")
+	listener := New(Config{
+		File:   file,
+		Listen: "80",
+		Check:  "echo 'invalid directive' >&2; false",
+		Exec:   fmt.Sprintf("touch %q", marker),
+	})
+
+	err := listener.Notify(context.Background(), testState())
+	if err == nil || !strings.Contains(err.Error(), "invalid directive") {
+		t.Fatalf("Notify() error = %v, want check error with command output", err)
+	}
+
+	data, err := os.ReadFile(file.Path)
+	if err != nil {
+		t.Fatalf("read configuration: %v", err)
+	}
+	if string(data) != "# previous\n" {
+		t.Errorf("configuration = %q, want previous content restored", data)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatalf("reload command ran after failed check: stat error = %v", err)
+	}
+}
+
+func TestNotifyReloadsOnlyAfterChange(t *testing.T) {
+	t.Parallel()
+
+	file := listenerstest.File(t, "consul.conf")
+	marker := filepath.Join(filepath.Dir(file.Path), "reloaded")
+	listener := New(Config{
+		File:   file,
+		Listen: "80",
+		Check:  "true",
+		Exec:   fmt.Sprintf("touch %q", marker),
+	})
+
+	if err := listener.Notify(context.Background(), testState()); err != nil {
+		t.Fatalf("first Notify() error = %v", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Fatalf("reload marker after changed configuration: %v", err)
+	}
+
+	if err := os.Remove(marker); err != nil {
+		t.Fatalf("remove reload marker: %v", err)
+	}
+	if err := listener.Notify(context.Background(), testState()); err != nil {
+		t.Fatalf("second Notify() error = %v", err)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatalf("reload command ran for unchanged configuration: stat error = %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package listeners
+
+import (
+	"log/slog"
+	"path"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/jfk9w/consul-publish/internal/consul"
+)
+
+var safeguardBlocked = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "consul_publish_safeguard_blocked_total",
+	Help: "Changes not applied by a listener because they would remove too many entries.",
+}, []string{"listener"})
+
+// Safeguard configures the protection against changes that remove most of the entries
+// at once, for example when Consul briefly returns an empty catalog.
+type Safeguard struct {
+	MaxDeletes     int     `yaml:"max-deletes,omitempty" doc:"Maximum number of entries a single change may remove; 0 disables the limit"`
+	MaxDeleteRatio float64 `yaml:"max-delete-ratio,omitempty" doc:"Maximum share of the applied entries a single change may remove, between 0 and 1; 0 disables the limit"`
+	Confirm        string  `yaml:"confirm,omitempty" default:"consul-publish/confirm" doc:"Consul KV prefix; writing a new value to <prefix>/<listener> confirms a blocked change"`
+}
+
+func (s Safeguard) enabled() bool {
+	return s.MaxDeletes > 0 || s.MaxDeleteRatio > 0
+}
+
+// Guard applies a Safeguard to the updates of a listener. A blocked change is applied
+// after a new value is written to the confirmation key of the listener.
+type Guard struct {
+	listener string
+	cfg      Safeguard
+
+	mu   sync.Mutex
+	init bool
+	seen string
+}
+
+// NewGuard creates a Guard for the named listener.
+func NewGuard(listener string, cfg Safeguard) *Guard {
+	return &Guard{listener: listener, cfg: cfg}
+}
+
+// KV returns the confirmation KV prefix to watch, if the safeguard is enabled.
+func (g *Guard) KV() []string {
+	if !g.cfg.enabled() || g.cfg.Confirm == "" {
+		return nil
+	}
+
+	return []string{g.cfg.Confirm}
+}
+
+// Begin starts an update of the listener to state. The update is confirmed when the value of the
+// confirmation key changed since the previous update.
+func (g *Guard) Begin(state *consul.State) *Check {
+	check, _ := g.BeginWith(func(key string) ([]byte, error) {
+		entry, _ := state.KV.Get(key).(consul.Value)
+		return entry, nil
+	})
+
+	return check
+}
+
+// BeginWith starts an update like Begin for a listener that does not watch the Consul state.
+// get returns the value of the confirmation key, or nil when the key does not exist.
+func (g *Guard) BeginWith(get func(key string) ([]byte, error)) (*Check, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	check := &Check{guard: g}
+	if !g.cfg.enabled() {
+		return check, nil
+	}
+
+	var value string
+	if g.cfg.Confirm != "" {
+		entry, err := get(g.key())
+		if err != nil {
+			return nil, errors.Wrapf(err, "read safeguard confirmation key %s", g.key())
+		}
+
+		value = string(entry)
+	}
+
+	check.confirmed = g.init && value != g.seen
+	g.init, g.seen = true, value
+	return check, nil
+}
+
+func (g *Guard) key() string {
+	return path.Join(g.cfg.Confirm, g.listener)
+}
+
+// Check guards a single update of a listener.
+type Check struct {
+	guard     *Guard
+	confirmed bool
+}
+
+// Allow reports whether a change that removes removed of total applied entries of kind
+// may be applied. Blocked changes are logged and counted in the metrics.
+func (c *Check) Allow(kind string, total, removed int) bool {
+	cfg := c.guard.cfg
+	exceeded := cfg.MaxDeletes > 0 && removed > cfg.MaxDeletes ||
+		cfg.MaxDeleteRatio > 0 && float64(removed) > cfg.MaxDeleteRatio*float64(total)
+	if !exceeded {
+		return true
+	}
+
+	log := slog.With("listener", c.guard.listener, "kind", kind, "removed", removed, "total", total)
+	if c.confirmed {
+		log.Warn("applying confirmed mass deletion")
+		return true
+	}
+
+	log.Error("SAFEGUARD: change would remove too many entries and was not applied; write a new value to the confirmation key to apply it",
+		"key", c.guard.key())
+	safeguardBlocked.WithLabelValues(c.guard.listener).Inc()
+	return false
+}
@@ -0,0 +1,41 @@
+package listeners
+
+import (
+	"sort"
+
+	"github.com/jfk9w/consul-publish/internal/consul"
+)
+
+// Instance is a single registration of a service on a node, as passed to service templates.
+type Instance struct {
+	Node    consul.Node
+	Service consul.Service
+}
+
+// GetInstances groups every service registration in state by service ID.
+// Addresses of services running on the local node are replaced with LocalIP.
+// Instances are sorted by address, then by node name.
+func GetInstances(state *consul.State) map[string][]Instance {
+	self := state.Nodes[state.Self]
+	services := make(map[string][]Instance)
+	for _, node := range state.Nodes {
+		for _, service := range node.Services {
+			service.Address = GetLocalAddress(self, service)
+			services[service.ID] = append(services[service.ID], Instance{
+				Node:    node,
+				Service: service,
+			})
+		}
+	}
+
+	for _, instances := range services {
+		sort.Slice(instances, func(i, j int) bool {
+			if instances[i].Service.Address == instances[j].Service.Address {
+				return instances[i].Node.Name < instances[j].Node.Name
+			}
+			return instances[i].Service.Address < instances[j].Service.Address
+		})
+	}
+
+	return services
+}
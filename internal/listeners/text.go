@@ -0,0 +1,28 @@
+package listeners
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var lineStart = regexp.MustCompile(`(?m)^`)
+
+// Indent prefixes every line of text with four spaces, so that configuration snippets
+// can be nested into a generated block.
+func Indent(text string) string {
+	return lineStart.ReplaceAllString(text, "    ")
+}
+
+// WriteIndented writes the trimmed text indented with Indent followed by a newline.
+// Blank text is not written.
+func WriteIndented(w io.Writer, text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	_, err := fmt.Fprintln(w, Indent(text))
+	return err
+}
@@ -0,0 +1,32 @@
+package mikrotik
+
+import "context"
+
+// AddressListEntry represents an entry in MikroTik /ip/firewall/address-list.
+// The ID field is populated by MikroTik and used for update/delete operations.
+type AddressListEntry struct {
+	ID      string `json:".id,omitempty"     url:".id,omitempty"`
+	List    string `json:"list,omitempty"    url:"list,omitempty"`
+	Address string `json:"address,omitempty" url:"address,omitempty"`
+	Comment string `json:"comment,omitempty" url:"comment,omitempty"`
+}
+
+// CreateAddressListEntry creates a new address list entry and returns it with the assigned ID.
+func (c *Client) CreateAddressListEntry(ctx context.Context, entry AddressListEntry) (AddressListEntry, error) {
+	return c.addressList.Create(ctx, entry)
+}
+
+// UpdateAddressListEntry updates the address list entry identified by entry.ID.
+func (c *Client) UpdateAddressListEntry(ctx context.Context, entry AddressListEntry) (AddressListEntry, error) {
+	return c.addressList.Update(ctx, entry.ID, entry)
+}
+
+// FindAddressListEntries returns all address list entries matching the non-zero fields of filter.
+func (c *Client) FindAddressListEntries(ctx context.Context, filter AddressListEntry) ([]AddressListEntry, error) {
+	return c.addressList.Find(ctx, filter)
+}
+
+// DeleteAddressListEntry deletes the address list entry with the given MikroTik ID (e.g. "*1").
+func (c *Client) DeleteAddressListEntry(ctx context.Context, id string) error {
+	return c.addressList.Delete(ctx, id)
+}
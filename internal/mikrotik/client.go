@@ -3,13 +3,15 @@ package mikrotik
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/google/go-querystring/query"
 	"github.com/pkg/errors"
 )
 
@@ -26,7 +28,7 @@ func (d *Duration) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &s); err != nil {
 		return err
 	}
-	v, err := time.ParseDuration(s)
+	v, err := parseDuration(s)
 	if err != nil {
 		return errors.Wrap(err, "parse duration")
 	}
@@ -34,6 +36,29 @@ func (d *Duration) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// parseDuration parses Go duration strings as well as the RouterOS format,
+// which prefixes them with week and day units (e.g. "1w2d3h").
+func parseDuration(s string) (time.Duration, error) {
+	var total time.Duration
+	for _, unit := range []struct {
+		suffix string
+		value  time.Duration
+	}{{"w", 7 * 24 * time.Hour}, {"d", 24 * time.Hour}} {
+		count, rest, ok := strings.Cut(s, unit.suffix)
+		if n, err := strconv.Atoi(count); ok && err == nil {
+			total += time.Duration(n) * unit.value
+			s = rest
+		}
+	}
+
+	if s == "" && total > 0 {
+		return total, nil
+	}
+
+	v, err := time.ParseDuration(s)
+	return total + v, err
+}
+
 func (d Duration) MarshalYAML() (any, error) {
 	return time.Duration(d).String(), nil
 }
@@ -51,29 +76,84 @@ func (d *Duration) UnmarshalYAML(unmarshal func(any) error) error {
 	return nil
 }
 
+// DNS record types supported by MikroTik /ip/dns/static. Records without a type are A records.
+const (
+	RecordA     = "A"
+	RecordAAAA  = "AAAA"
+	RecordCNAME = "CNAME"
+	RecordTXT   = "TXT"
+	RecordFWD   = "FWD"
+)
+
 // DNSRecord represents a static DNS entry in MikroTik /ip/dns/static.
 // The ID field is populated by MikroTik and used for update/delete operations.
+// A record matches either Name or, when set, Regexp. The data field depends on the type:
+// Address for A and AAAA, CName for CNAME, Text for TXT and ForwardTo for FWD.
 type DNSRecord struct {
-	ID      string   `json:".id,omitempty"     url:".id,omitempty"`
-	Name    string   `json:"name,omitempty"    url:"name,omitempty"`
-	Address string   `json:"address,omitempty" url:"address,omitempty"`
-	TTL     Duration `json:"ttl,omitempty"     url:"-"`
-	Comment string   `json:"comment,omitempty" url:"comment,omitempty"`
+	ID        string   `json:".id,omitempty"        url:".id,omitempty"`
+	Name      string   `json:"name,omitempty"       url:"name,omitempty"`
+	Regexp    string   `json:"regexp,omitempty"     url:"regexp,omitempty"`
+	Type      string   `json:"type,omitempty"       url:"type,omitempty"`
+	Address   string   `json:"address,omitempty"    url:"address,omitempty"`
+	CName     string   `json:"cname,omitempty"      url:"cname,omitempty"`
+	Text      string   `json:"text,omitempty"       url:"text,omitempty"`
+	ForwardTo string   `json:"forward-to,omitempty" url:"forward-to,omitempty"`
+	TTL       Duration `json:"ttl,omitempty"        url:"-"`
+	Comment   string   `json:"comment,omitempty"    url:"comment,omitempty"`
+}
+
+// RecordType returns the record type, defaulting to A.
+func (r DNSRecord) RecordType() string {
+	if r.Type == "" {
+		return RecordA
+	}
+
+	return r.Type
+}
+
+// Data returns the value of the data field used by the record type.
+func (r DNSRecord) Data() string {
+	switch r.RecordType() {
+	case RecordCNAME:
+		return r.CName
+	case RecordTXT:
+		return r.Text
+	case RecordFWD:
+		return r.ForwardTo
+	default:
+		return r.Address
+	}
 }
 
 // Config holds connection parameters for the MikroTik REST API.
 type Config struct {
-	Host     string `yaml:"host"`
-	User     string `yaml:"user"`
-	Password string `yaml:"password"`
+	Host               string   `yaml:"host"`
+	User               string   `yaml:"user"`
+	Password           string   `yaml:"password"`
+	Scheme             string   `yaml:"scheme,omitempty" default:"http" doc:"REST API scheme, http or https"`
+	CA                 string   `yaml:"ca,omitempty" doc:"Path to a PEM bundle with the CA certificates that sign the router certificate"`
+	Fingerprint        string   `yaml:"fingerprint,omitempty" doc:"Hex-encoded SHA-256 fingerprint of the router certificate; when set, only this certificate is accepted"`
+	InsecureSkipVerify bool     `yaml:"insecure-skip-verify,omitempty" doc:"Skip router certificate verification"`
+	Timeout            Duration `yaml:"timeout,omitempty" default:"10s" doc:"Timeout of a single REST API request"`
+	Retries            int      `yaml:"retries,omitempty" default:"3" doc:"Number of retries of idempotent requests (GET, PATCH and DELETE) after connection errors and 5xx responses"`
+	Backoff            Duration `yaml:"backoff,omitempty" default:"1s" doc:"Delay before the first retry; doubled after every attempt"`
 }
 
 // Client is a MikroTik REST API client. Use New to construct one.
 type Client struct {
+	host     string
 	baseURL  string
 	user     string
 	password string
+	timeout  time.Duration
+	retries  int
+	backoff  time.Duration
 	http     HTTPClient
+
+	dnsStatic   *Resource[DNSRecord]
+	addressList *Resource[AddressListEntry]
+	dhcpLeases  *Resource[DHCPLease]
+	nat         *Resource[NATRule]
 }
 
 // Option is a functional option for Client.
@@ -85,109 +165,134 @@ func WithHTTPClient(h HTTPClient) Option {
 }
 
 // New creates a Client that talks to the MikroTik REST API at cfg.Host.
-func New(cfg Config, opts ...Option) *Client {
+func New(cfg Config, opts ...Option) (*Client, error) {
+	scheme := cfg.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	if scheme != "http" && scheme != "https" {
+		return nil, errors.Errorf("unsupported scheme %q", scheme)
+	}
+
+	tlsCfg, err := tlsConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsCfg
+
 	c := &Client{
-		baseURL:  "http://" + cfg.Host + "/rest",
+		host:     cfg.Host,
+		baseURL:  scheme + "://" + cfg.Host + "/rest",
 		user:     cfg.User,
 		password: cfg.Password,
-		http:     http.DefaultClient,
+		timeout:  time.Duration(cfg.Timeout),
+		retries:  cfg.Retries,
+		backoff:  time.Duration(cfg.Backoff),
+		http:     &http.Client{Transport: transport},
 	}
+	c.dnsStatic = NewResource[DNSRecord](c, "/ip/dns/static")
+	c.addressList = NewResource[AddressListEntry](c, "/ip/firewall/address-list")
+	c.dhcpLeases = NewResource[DHCPLease](c, "/ip/dhcp-server/lease")
+	c.nat = NewResource[NATRule](c, "/ip/firewall/nat")
 	for _, opt := range opts {
 		opt(c)
 	}
-	return c
+	return c, nil
 }
 
 // CreateDNSRecord creates a new static DNS record and returns it with the assigned ID.
-func (c *Client) CreateDNSRecord(record DNSRecord) (DNSRecord, error) {
-	resp, data, err := c.do("PUT", "/ip/dns/static", record)
-	if err != nil {
-		return DNSRecord{}, err
-	}
-	if resp.StatusCode != http.StatusCreated {
-		return DNSRecord{}, errors.Errorf("expected 201, got %d: %s", resp.StatusCode, data)
-	}
-	var created DNSRecord
-	if err := json.Unmarshal(data, &created); err != nil {
-		return DNSRecord{}, errors.Wrap(err, "unmarshal response")
-	}
-	return created, nil
+func (c *Client) CreateDNSRecord(ctx context.Context, record DNSRecord) (DNSRecord, error) {
+	return c.dnsStatic.Create(ctx, record)
 }
 
 // UpdateDNSRecord updates the DNS record identified by record.ID.
-func (c *Client) UpdateDNSRecord(record DNSRecord) (DNSRecord, error) {
-	resp, data, err := c.do("PATCH", fmt.Sprintf("/ip/dns/static/%s", record.ID), record)
-	if err != nil {
-		return DNSRecord{}, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return DNSRecord{}, errors.Errorf("expected 200, got %d: %s", resp.StatusCode, data)
-	}
-	var updated DNSRecord
-	if err := json.Unmarshal(data, &updated); err != nil {
-		return DNSRecord{}, errors.Wrap(err, "unmarshal response")
-	}
-	return updated, nil
+func (c *Client) UpdateDNSRecord(ctx context.Context, record DNSRecord) (DNSRecord, error) {
+	return c.dnsStatic.Update(ctx, record.ID, record)
 }
 
 // FindDNSRecords returns all static DNS records matching the non-zero fields of filter.
-func (c *Client) FindDNSRecords(filter DNSRecord) ([]DNSRecord, error) {
-	params, err := query.Values(filter)
-	if err != nil {
-		return nil, errors.Wrap(err, "encode filter")
-	}
-	path := "/ip/dns/static"
-	if len(params) > 0 {
-		path += "?" + params.Encode()
-	}
-	resp, data, err := c.do("GET", path, nil)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.Errorf("expected 200, got %d: %s", resp.StatusCode, data)
-	}
-	var records []DNSRecord
-	if err := json.Unmarshal(data, &records); err != nil {
-		return nil, errors.Wrap(err, "unmarshal response")
-	}
-	return records, nil
+func (c *Client) FindDNSRecords(ctx context.Context, filter DNSRecord) ([]DNSRecord, error) {
+	return c.dnsStatic.Find(ctx, filter)
 }
 
 // DeleteDNSRecord deletes the static DNS record with the given MikroTik ID (e.g. "*1").
-func (c *Client) DeleteDNSRecord(id string) error {
-	resp, data, err := c.do("DELETE", fmt.Sprintf("/ip/dns/static/%s", id), nil)
-	if err != nil {
-		return err
-	}
-	if resp.StatusCode != http.StatusNoContent {
-		return errors.Errorf("expected 204, got %d: %s", resp.StatusCode, data)
-	}
-	return nil
+func (c *Client) DeleteDNSRecord(ctx context.Context, id string) error {
+	return c.dnsStatic.Delete(ctx, id)
 }
 
-func (c *Client) do(method, path string, body any) (*http.Response, []byte, error) {
-	var bodyReader io.Reader
+// do sends the request and returns the response with its body. Idempotent requests are
+// retried with exponential backoff after connection errors and 5xx responses. A retried
+// DELETE that gets 404 is reported as 204, as the entry is gone either way.
+func (c *Client) do(ctx context.Context, method, path string, body any) (*http.Response, []byte, error) {
+	var payload []byte
 	if body != nil {
 		data, err := json.Marshal(body)
 		if err != nil {
 			return nil, nil, errors.Wrap(err, "marshal body")
 		}
-		bodyReader = bytes.NewReader(data)
+		payload = data
+	}
+
+	attempts := 1
+	if method != http.MethodPut && method != http.MethodPost {
+		attempts += max(c.retries, 0)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, data, err := c.send(ctx, method, path, payload)
+		switch {
+		case err == nil && attempt > 1 && method == http.MethodDelete && resp.StatusCode == http.StatusNotFound:
+			// An earlier attempt may have deleted the entry before its response was lost.
+			slog.Info("MikroTik entry already deleted by an earlier attempt", "path", path, "attempt", attempt)
+			resp.StatusCode = http.StatusNoContent
+			return resp, nil, nil
+		case attempt >= attempts || ctx.Err() != nil:
+			return resp, data, err
+		case err != nil:
+			slog.Warn("MikroTik request failed, retrying", "method", method, "path", path, "attempt", attempt, "error", err)
+		case resp.StatusCode >= http.StatusInternalServerError:
+			slog.Warn("MikroTik request failed, retrying", "method", method, "path", path, "attempt", attempt, "status", resp.StatusCode)
+		default:
+			return resp, data, nil
+		}
+
+		select {
+		case <-time.After(c.backoff << (attempt - 1)):
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, path string, payload []byte) (*http.Response, []byte, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
 	}
-	req, err := http.NewRequest(method, c.baseURL+path, bodyReader)
+
+	var bodyReader io.Reader
+	if payload != nil {
+		bodyReader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "new request")
 	}
 	req.SetBasicAuth(c.user, c.password)
-	if body != nil {
+	if payload != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 	resp, err := c.http.Do(req)
 	if err != nil {
+		observeRequest(c.host, method, 0, err)
 		return nil, nil, errors.Wrap(err, "do request")
 	}
 	defer resp.Body.Close()
+	observeRequest(c.host, method, resp.StatusCode, nil)
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "read body")
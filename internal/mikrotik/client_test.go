@@ -1,13 +1,17 @@
 package mikrotik_test
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
@@ -46,6 +50,8 @@ func TestDuration_UnmarshalJSON(t *testing.T) {
 		{`"1m30s"`, mikrotik.Duration(90 * time.Second)},
 		{`"24h0m0s"`, mikrotik.Duration(24 * time.Hour)},
 		{`"25h5m0s"`, mikrotik.Duration(25*time.Hour + 5*time.Minute)},
+		{`"1d"`, mikrotik.Duration(24 * time.Hour)},
+		{`"1w2d3h4m5s"`, mikrotik.Duration(9*24*time.Hour + 3*time.Hour + 4*time.Minute + 5*time.Second)},
 	}
 	for _, tc := range cases {
 		var d mikrotik.Duration
@@ -93,10 +99,12 @@ func TestDuration_UnmarshalYAML(t *testing.T) {
 
 func newMockClient(t *testing.T, mockHTTP *MockHTTPClient) *mikrotik.Client {
 	t.Helper()
-	return mikrotik.New(
+	c, err := mikrotik.New(
 		mikrotik.Config{Host: "router.local", User: "admin", Password: "secret"},
 		mikrotik.WithHTTPClient(mockHTTP),
 	)
+	require.NoError(t, err)
+	return c
 }
 
 func makeResponse(status int, body string) *http.Response {
@@ -130,7 +138,7 @@ func TestClient_CreateDNSRecord(t *testing.T) {
 		})
 
 	c := newMockClient(t, mockHTTP)
-	got, err := c.CreateDNSRecord(mikrotik.DNSRecord{
+	got, err := c.CreateDNSRecord(context.Background(), mikrotik.DNSRecord{
 		Name:    "test.local",
 		Address: "10.0.0.1",
 		TTL:     mikrotik.Duration(5 * time.Minute),
@@ -149,7 +157,7 @@ func TestClient_CreateDNSRecord_ErrorStatus(t *testing.T) {
 		Return(makeResponse(http.StatusBadRequest, `bad request`), nil)
 
 	c := newMockClient(t, mockHTTP)
-	_, err := c.CreateDNSRecord(mikrotik.DNSRecord{Name: "x"})
+	_, err := c.CreateDNSRecord(context.Background(), mikrotik.DNSRecord{Name: "x"})
 	assert.Error(t, err)
 }
 
@@ -172,7 +180,7 @@ func TestClient_UpdateDNSRecord(t *testing.T) {
 		})
 
 	c := newMockClient(t, mockHTTP)
-	got, err := c.UpdateDNSRecord(mikrotik.DNSRecord{
+	got, err := c.UpdateDNSRecord(context.Background(), mikrotik.DNSRecord{
 		ID:      "*1",
 		Name:    "test.local",
 		Address: "10.0.0.2",
@@ -199,7 +207,7 @@ func TestClient_FindDNSRecords(t *testing.T) {
 		})
 
 	c := newMockClient(t, mockHTTP)
-	records, err := c.FindDNSRecords(mikrotik.DNSRecord{Comment: "consul"})
+	records, err := c.FindDNSRecords(context.Background(), mikrotik.DNSRecord{Comment: "consul"})
 	require.NoError(t, err)
 	require.Len(t, records, 2)
 	assert.Equal(t, "*1", records[0].ID)
@@ -215,7 +223,7 @@ func TestClient_FindDNSRecords_Empty(t *testing.T) {
 		Return(makeResponse(http.StatusOK, `[]`), nil)
 
 	c := newMockClient(t, mockHTTP)
-	records, err := c.FindDNSRecords(mikrotik.DNSRecord{Comment: "consul"})
+	records, err := c.FindDNSRecords(context.Background(), mikrotik.DNSRecord{Comment: "consul"})
 	require.NoError(t, err)
 	assert.Empty(t, records)
 }
@@ -233,7 +241,7 @@ func TestClient_DeleteDNSRecord(t *testing.T) {
 		})
 
 	c := newMockClient(t, mockHTTP)
-	require.NoError(t, c.DeleteDNSRecord("*1"))
+	require.NoError(t, c.DeleteDNSRecord(context.Background(), "*1"))
 }
 
 func TestClient_DeleteDNSRecord_ErrorStatus(t *testing.T) {
@@ -245,5 +253,191 @@ func TestClient_DeleteDNSRecord_ErrorStatus(t *testing.T) {
 		Return(makeResponse(http.StatusNotFound, `not found`), nil)
 
 	c := newMockClient(t, mockHTTP)
-	assert.Error(t, c.DeleteDNSRecord("*999"))
+	assert.Error(t, c.DeleteDNSRecord(context.Background(), "*999"))
+}
+
+func TestClient_FindDNSRecords_Types(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockHTTP := NewMockHTTPClient(ctrl)
+
+	mockHTTP.EXPECT().
+		Do(gomock.Any()).
+		Return(makeResponse(http.StatusOK,
+			`[{".id":"*1","name":"a.local","address":"10.0.0.1"},`+
+				`{".id":"*2","name":"b.local","type":"CNAME","cname":"node1.lan"},`+
+				`{".id":"*3","name":"c.local","type":"TXT","text":"v=spf1 -all"},`+
+				`{".id":"*4","regexp":"^.+\\.lan$","type":"FWD","forward-to":"10.0.0.53"},`+
+				`{".id":"*5","name":"d.local","type":"AAAA","address":"fd00::1"}]`), nil)
+
+	c := newMockClient(t, mockHTTP)
+	records, err := c.FindDNSRecords(context.Background(), mikrotik.DNSRecord{Comment: "consul"})
+	require.NoError(t, err)
+	require.Len(t, records, 5)
+
+	for i, want := range []struct{ typ, data string }{
+		{mikrotik.RecordA, "10.0.0.1"},
+		{mikrotik.RecordCNAME, "node1.lan"},
+		{mikrotik.RecordTXT, "v=spf1 -all"},
+		{mikrotik.RecordFWD, "10.0.0.53"},
+		{mikrotik.RecordAAAA, "fd00::1"},
+	} {
+		assert.Equal(t, want.typ, records[i].RecordType())
+		assert.Equal(t, want.data, records[i].Data())
+	}
+	assert.Equal(t, `^.+\.lan$`, records[3].Regexp)
+}
+
+func newRetryingClient(t *testing.T, mockHTTP *MockHTTPClient) *mikrotik.Client {
+	t.Helper()
+	c, err := mikrotik.New(
+		mikrotik.Config{Host: "router.local", Retries: 2, Backoff: mikrotik.Duration(time.Millisecond)},
+		mikrotik.WithHTTPClient(mockHTTP),
+	)
+	require.NoError(t, err)
+	return c
+}
+
+func TestClient_RetriesIdempotentRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockHTTP := NewMockHTTPClient(ctrl)
+
+	gomock.InOrder(
+		mockHTTP.EXPECT().Do(gomock.Any()).Return(nil, errors.New("connection refused")),
+		mockHTTP.EXPECT().Do(gomock.Any()).Return(makeResponse(http.StatusServiceUnavailable, `busy`), nil),
+		mockHTTP.EXPECT().Do(gomock.Any()).Return(makeResponse(http.StatusOK, `[]`), nil),
+	)
+
+	c := newRetryingClient(t, mockHTTP)
+	records, err := c.FindDNSRecords(context.Background(), mikrotik.DNSRecord{Comment: "consul"})
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestClient_RetriesGiveUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockHTTP := NewMockHTTPClient(ctrl)
+
+	mockHTTP.EXPECT().Do(gomock.Any()).
+		DoAndReturn(func(req *http.Request) (*http.Response, error) {
+			return makeResponse(http.StatusInternalServerError, `error`), nil
+		}).
+		Times(3)
+
+	c := newRetryingClient(t, mockHTTP)
+	assert.ErrorContains(t, c.DeleteDNSRecord(context.Background(), "*1"), "expected 204, got 500")
+}
+
+func TestClient_RetriedDeleteOfDeletedEntry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockHTTP := NewMockHTTPClient(ctrl)
+
+	gomock.InOrder(
+		mockHTTP.EXPECT().Do(gomock.Any()).Return(nil, errors.New("connection reset by peer")),
+		mockHTTP.EXPECT().Do(gomock.Any()).Return(makeResponse(http.StatusNotFound, `not found`), nil),
+	)
+
+	c := newRetryingClient(t, mockHTTP)
+	assert.NoError(t, c.DeleteDNSRecord(context.Background(), "*1"))
+}
+
+func TestClient_DoesNotRetryCreate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockHTTP := NewMockHTTPClient(ctrl)
+
+	mockHTTP.EXPECT().Do(gomock.Any()).Return(makeResponse(http.StatusInternalServerError, `error`), nil)
+
+	c := newRetryingClient(t, mockHTTP)
+	_, err := c.CreateDNSRecord(context.Background(), mikrotik.DNSRecord{Name: "x"})
+	assert.Error(t, err)
+}
+
+func TestClient_Timeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockHTTP := NewMockHTTPClient(ctrl)
+
+	mockHTTP.EXPECT().Do(gomock.Any()).
+		DoAndReturn(func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		})
+
+	c, err := mikrotik.New(
+		mikrotik.Config{Host: "router.local", Timeout: mikrotik.Duration(10 * time.Millisecond)},
+		mikrotik.WithHTTPClient(mockHTTP),
+	)
+	require.NoError(t, err)
+
+	_, err = c.FindDNSRecords(context.Background(), mikrotik.DNSRecord{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestClient_AddressListEntries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockHTTP := NewMockHTTPClient(ctrl)
+
+	gomock.InOrder(
+		mockHTTP.EXPECT().
+			Do(gomock.Any()).
+			DoAndReturn(func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, http.MethodGet, req.Method)
+				assert.Equal(t, "/rest/ip/firewall/address-list", req.URL.Path)
+				assert.Equal(t, "consul", req.URL.Query().Get("comment"))
+				return makeResponse(http.StatusOK,
+					`[{".id":"*1","list":"servers","address":"10.0.0.1","comment":"consul"}]`), nil
+			}),
+		mockHTTP.EXPECT().
+			Do(gomock.Any()).
+			DoAndReturn(func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, http.MethodPut, req.Method)
+				assert.Equal(t, "/rest/ip/firewall/address-list", req.URL.Path)
+				return makeResponse(http.StatusCreated,
+					`{".id":"*2","list":"iot","address":"10.0.0.2","comment":"consul"}`), nil
+			}),
+		mockHTTP.EXPECT().
+			Do(gomock.Any()).
+			DoAndReturn(func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, http.MethodDelete, req.Method)
+				assert.Equal(t, "/rest/ip/firewall/address-list/*1", req.URL.Path)
+				return makeResponse(http.StatusNoContent, ``), nil
+			}),
+	)
+
+	c := newMockClient(t, mockHTTP)
+	entries, err := c.FindAddressListEntries(context.Background(), mikrotik.AddressListEntry{Comment: "consul"})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "servers", entries[0].List)
+
+	created, err := c.CreateAddressListEntry(context.Background(), mikrotik.AddressListEntry{List: "iot", Address: "10.0.0.2", Comment: "consul"})
+	require.NoError(t, err)
+	assert.Equal(t, "*2", created.ID)
+
+	require.NoError(t, c.DeleteAddressListEntry(context.Background(), "*1"))
+}
+
+func TestClient_CountsRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockHTTP := NewMockHTTPClient(ctrl)
+
+	gomock.InOrder(
+		mockHTTP.EXPECT().Do(gomock.Any()).Return(nil, errors.New("connection refused")),
+		mockHTTP.EXPECT().Do(gomock.Any()).Return(makeResponse(http.StatusOK, `[]`), nil),
+	)
+
+	c, err := mikrotik.New(
+		mikrotik.Config{Host: "counts-requests.local", Retries: 1, Backoff: mikrotik.Duration(time.Millisecond)},
+		mikrotik.WithHTTPClient(mockHTTP),
+	)
+	require.NoError(t, err)
+
+	requests := mikrotik.Collectors()[0].(*prometheus.CounterVec)
+	failed := requests.WithLabelValues("counts-requests.local", http.MethodGet, "error")
+	succeeded := requests.WithLabelValues("counts-requests.local", http.MethodGet, "200")
+	failedBefore, succeededBefore := testutil.ToFloat64(failed), testutil.ToFloat64(succeeded)
+
+	_, err = c.FindDNSRecords(context.Background(), mikrotik.DNSRecord{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(failed)-failedBefore)
+	assert.Equal(t, 1.0, testutil.ToFloat64(succeeded)-succeededBefore)
 }
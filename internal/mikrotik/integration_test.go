@@ -3,6 +3,7 @@
 package mikrotik_test
 
 import (
+	"context"
 	"os"
 	"testing"
 	"time"
@@ -23,11 +24,19 @@ func newIntegrationClient(t *testing.T) *mikrotik.Client {
 		t.Skip("MIKROTIK_HOST, MIKROTIK_USER, MIKROTIK_PASSWORD not set")
 	}
 
-	return mikrotik.New(mikrotik.Config{
-		Host:     host,
-		User:     user,
-		Password: password,
+	c, err := mikrotik.New(mikrotik.Config{
+		Host:               host,
+		User:               user,
+		Password:           password,
+		Scheme:             os.Getenv("MIKROTIK_SCHEME"),
+		Fingerprint:        os.Getenv("MIKROTIK_FINGERPRINT"),
+		InsecureSkipVerify: os.Getenv("MIKROTIK_INSECURE") != "",
 	})
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+
+	return c
 }
 
 func TestCreateDNSRecord(t *testing.T) {
@@ -39,7 +48,7 @@ func TestCreateDNSRecord(t *testing.T) {
 		t.Skip("MIKROTIK_TEST_NAME, MIKROTIK_TEST_ADDRESS not set")
 	}
 
-	record, err := c.CreateDNSRecord(mikrotik.DNSRecord{
+	record, err := c.CreateDNSRecord(context.Background(), mikrotik.DNSRecord{
 		Name:    name,
 		Address: address,
 		TTL:     mikrotik.Duration(5 * time.Minute),
@@ -63,7 +72,7 @@ func TestDeleteDNSRecord(t *testing.T) {
 		t.Skip("MIKROTIK_TEST_NAME, MIKROTIK_TEST_ADDRESS not set")
 	}
 
-	records, err := c.FindDNSRecords(mikrotik.DNSRecord{Name: name})
+	records, err := c.FindDNSRecords(context.Background(), mikrotik.DNSRecord{Name: name})
 	if err != nil {
 		t.Fatalf("find dns records: %v", err)
 	}
@@ -72,7 +81,7 @@ func TestDeleteDNSRecord(t *testing.T) {
 
 	if len(records) == 0 {
 		t.Log("record not found, creating")
-		record, err := c.CreateDNSRecord(mikrotik.DNSRecord{
+		record, err := c.CreateDNSRecord(context.Background(), mikrotik.DNSRecord{
 			Name:    name,
 			Address: address,
 			TTL:     mikrotik.Duration(5 * time.Minute),
@@ -87,11 +96,11 @@ func TestDeleteDNSRecord(t *testing.T) {
 	id := records[0].ID
 	t.Logf("deleting record: id=%s name=%s", id, records[0].Name)
 
-	if err := c.DeleteDNSRecord(id); err != nil {
+	if err := c.DeleteDNSRecord(context.Background(), id); err != nil {
 		t.Fatalf("delete dns record: %v", err)
 	}
 
-	remaining, err := c.FindDNSRecords(mikrotik.DNSRecord{Name: name})
+	remaining, err := c.FindDNSRecords(context.Background(), mikrotik.DNSRecord{Name: name})
 	if err != nil {
 		t.Fatalf("find after delete: %v", err)
 	}
@@ -104,7 +113,7 @@ func TestDeleteDNSRecord(t *testing.T) {
 func TestListManagedDNSRecords(t *testing.T) {
 	c := newIntegrationClient(t)
 
-	records, err := c.FindDNSRecords(mikrotik.DNSRecord{Comment: managedByComment})
+	records, err := c.FindDNSRecords(context.Background(), mikrotik.DNSRecord{Comment: managedByComment})
 	if err != nil {
 		t.Fatalf("find managed dns records: %v", err)
 	}
@@ -0,0 +1,22 @@
+package mikrotik
+
+import "context"
+
+// DHCPLeaseBound is the status of a DHCP lease currently held by a client.
+const DHCPLeaseBound = "bound"
+
+// DHCPLease represents an entry in MikroTik /ip/dhcp-server/lease.
+type DHCPLease struct {
+	ID         string `json:".id,omitempty"         url:".id,omitempty"`
+	Server     string `json:"server,omitempty"      url:"server,omitempty"`
+	Address    string `json:"address,omitempty"     url:"address,omitempty"`
+	MACAddress string `json:"mac-address,omitempty" url:"mac-address,omitempty"`
+	HostName   string `json:"host-name,omitempty"   url:"host-name,omitempty"`
+	Status     string `json:"status,omitempty"      url:"status,omitempty"`
+	Comment    string `json:"comment,omitempty"     url:"comment,omitempty"`
+}
+
+// FindDHCPLeases returns all DHCP leases matching the non-zero fields of filter.
+func (c *Client) FindDHCPLeases(ctx context.Context, filter DHCPLease) ([]DHCPLease, error) {
+	return c.dhcpLeases.Find(ctx, filter)
+}
@@ -0,0 +1,26 @@
+package mikrotik
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "consul_publish_mikrotik_requests_total",
+	Help: "MikroTik REST API requests by router, HTTP method and response status; status is \"error\" when no response was received.",
+}, []string{"router", "method", "status"})
+
+// Collectors returns the Prometheus collectors maintained by the MikroTik client.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{requests}
+}
+
+func observeRequest(router, method string, status int, err error) {
+	label := "error"
+	if err == nil {
+		label = strconv.Itoa(status)
+	}
+
+	requests.WithLabelValues(router, method, label).Inc()
+}
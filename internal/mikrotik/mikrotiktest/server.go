@@ -0,0 +1,325 @@
+// Package mikrotiktest provides an in-memory stand-in for the RouterOS REST API,
+// so that tests can check the final state of a router instead of individual calls.
+package mikrotiktest
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jfk9w/consul-publish/internal/mikrotik"
+)
+
+// Menu paths served by Server, relative to /rest.
+const (
+	DNSStatic   = "/ip/dns/static"
+	AddressList = "/ip/firewall/address-list"
+	DHCPLease   = "/ip/dhcp-server/lease"
+	NAT         = "/ip/firewall/nat"
+)
+
+const (
+	user     = "admin"
+	password = "secret"
+)
+
+// entry is a RouterOS menu entry. Like RouterOS itself, the server keeps all properties as strings.
+type entry map[string]string
+
+// Server is an httptest server implementing the RouterOS REST API for the supported menus:
+// PUT creates an entry and allocates its .id, PATCH updates it, GET lists entries with
+// property filters and .proplist, and DELETE removes it. Errors are reported with
+// RouterOS-style JSON bodies.
+type Server struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	menus  map[string]map[string]entry
+	lastID int
+}
+
+// NewServer starts a Server with empty DNSStatic, AddressList, DHCPLease and NAT menus.
+// The caller must call Close when finished.
+func NewServer() *Server {
+	s := &Server{menus: map[string]map[string]entry{
+		DNSStatic:   {},
+		AddressList: {},
+		DHCPLease:   {},
+		NAT:         {},
+	}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Config returns the client configuration for the server.
+func (s *Server) Config() mikrotik.Config {
+	return mikrotik.Config{
+		Host:     strings.TrimPrefix(s.URL, "http://"),
+		User:     user,
+		Password: password,
+	}
+}
+
+// Add stores value (a struct with json tags, such as mikrotik.DNSRecord) in the menu
+// at path and returns its allocated .id.
+func (s *Server) Add(path string, value any) string {
+	e, err := toEntry(value)
+	if err != nil {
+		panic(err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.add(s.menus[path], e)
+}
+
+// DNSRecords returns the static DNS records ordered by .id.
+func (s *Server) DNSRecords() []mikrotik.DNSRecord {
+	return list[mikrotik.DNSRecord](s, DNSStatic)
+}
+
+// AddressListEntries returns the firewall address list entries ordered by .id.
+func (s *Server) AddressListEntries() []mikrotik.AddressListEntry {
+	return list[mikrotik.AddressListEntry](s, AddressList)
+}
+
+// NATRules returns the firewall NAT rules ordered by .id.
+func (s *Server) NATRules() []mikrotik.NATRule {
+	return list[mikrotik.NATRule](s, NAT)
+}
+
+func list[T any](s *Server, path string) []T {
+	s.mu.Lock()
+	data, err := json.Marshal(sorted(s.menus[path]))
+	s.mu.Unlock()
+	if err != nil {
+		panic(err)
+	}
+
+	var values []T
+	if err := json.Unmarshal(data, &values); err != nil {
+		panic(err)
+	}
+
+	return values
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if u, p, ok := r.BasicAuth(); !ok || u != user || p != password {
+		writeError(w, http.StatusUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	path, ok := strings.CutPrefix(r.URL.Path, "/rest")
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found", "")
+		return
+	}
+
+	var id string
+	if i := strings.LastIndex(path, "/"); i >= 0 && strings.HasPrefix(path[i+1:], "*") {
+		path, id = path[:i], path[i+1:]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	menu, ok := s.menus[path]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Bad Request", "no such command")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		s.find(w, r, menu)
+	case r.Method == http.MethodPut && id == "":
+		s.create(w, r, path, menu)
+	case r.Method == http.MethodPatch && id != "":
+		s.update(w, r, path, menu, id)
+	case r.Method == http.MethodDelete && id != "":
+		s.delete(w, menu, id)
+	default:
+		writeError(w, http.StatusBadRequest, "Bad Request", "unsupported method")
+	}
+}
+
+func (s *Server) find(w http.ResponseWriter, r *http.Request, menu map[string]entry) {
+	query := r.URL.Query()
+	var proplist []string
+	if value := query.Get(".proplist"); value != "" {
+		proplist = strings.Split(value, ",")
+	}
+	query.Del(".proplist")
+
+	result := make([]entry, 0, len(menu))
+	for _, e := range sorted(menu) {
+		if !matches(e, query) {
+			continue
+		}
+
+		if proplist != nil {
+			props := make(entry, len(proplist))
+			for _, key := range proplist {
+				if value, ok := e[key]; ok {
+					props[key] = value
+				}
+			}
+
+			e = props
+		}
+
+		result = append(result, e)
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request, path string, menu map[string]entry) {
+	e, ok := decode(w, r)
+	if !ok {
+		return
+	}
+
+	delete(e, ".id")
+	if detail := validate(path, e); detail != "" {
+		writeError(w, http.StatusBadRequest, "Bad Request", detail)
+		return
+	}
+
+	id := s.add(menu, e)
+	writeJSON(w, http.StatusCreated, menu[id])
+}
+
+func (s *Server) update(w http.ResponseWriter, r *http.Request, path string, menu map[string]entry, id string) {
+	current, ok := menu[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found", "no such item")
+		return
+	}
+
+	changes, ok := decode(w, r)
+	if !ok {
+		return
+	}
+
+	updated := maps.Clone(current)
+	maps.Copy(updated, changes)
+	updated[".id"] = id
+	if detail := validate(path, updated); detail != "" {
+		writeError(w, http.StatusBadRequest, "Bad Request", detail)
+		return
+	}
+
+	menu[id] = updated
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func (s *Server) delete(w http.ResponseWriter, menu map[string]entry, id string) {
+	if _, ok := menu[id]; !ok {
+		writeError(w, http.StatusNotFound, "Not Found", "no such item")
+		return
+	}
+
+	delete(menu, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) add(menu map[string]entry, e entry) string {
+	s.lastID++
+	id := "*" + strings.ToUpper(strconv.FormatInt(int64(s.lastID), 16))
+	e[".id"] = id
+	menu[id] = e
+	return id
+}
+
+// validate returns the RouterOS error detail for an invalid entry, or an empty string.
+func validate(path string, e entry) string {
+	switch path {
+	case DNSStatic:
+		if (e["name"] == "") == (e["regexp"] == "") {
+			return "either name or regexp must be set"
+		}
+	case AddressList:
+		if e["list"] == "" || e["address"] == "" {
+			return "list and address must be set"
+		}
+	case NAT:
+		if e["chain"] == "" {
+			return "chain must be set"
+		}
+	}
+
+	return ""
+}
+
+func matches(e entry, query map[string][]string) bool {
+	for key, values := range query {
+		if len(values) > 0 && e[key] != values[0] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sorted(menu map[string]entry) []entry {
+	return slices.SortedFunc(maps.Values(menu), func(a, b entry) int {
+		return cmp.Or(cmp.Compare(len(a[".id"]), len(b[".id"])), cmp.Compare(a[".id"], b[".id"]))
+	})
+}
+
+func decode(w http.ResponseWriter, r *http.Request) (entry, bool) {
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request", err.Error())
+		return nil, false
+	}
+
+	return stringify(body), true
+}
+
+func toEntry(value any) (entry, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, err
+	}
+
+	return stringify(body), nil
+}
+
+func stringify(body map[string]any) entry {
+	e := make(entry, len(body))
+	for key, value := range body {
+		e[key] = fmt.Sprint(value)
+	}
+
+	return e
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message, detail string) {
+	body := map[string]any{"error": status, "message": message}
+	if detail != "" {
+		body["detail"] = detail
+	}
+
+	writeJSON(w, status, body)
+}
@@ -0,0 +1,76 @@
+package mikrotiktest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jfk9w/consul-publish/internal/mikrotik"
+	"github.com/jfk9w/consul-publish/internal/mikrotik/mikrotiktest"
+)
+
+func TestServer_DNSRecords(t *testing.T) {
+	srv := mikrotiktest.NewServer()
+	defer srv.Close()
+
+	client, err := mikrotik.New(srv.Config())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	foreign := srv.Add(mikrotiktest.DNSStatic, mikrotik.DNSRecord{Name: "router.lan", Address: "10.0.0.254"})
+
+	created, err := client.CreateDNSRecord(ctx, mikrotik.DNSRecord{Name: "svc.local", Address: "10.0.0.1", Comment: "consul"})
+	require.NoError(t, err)
+	require.Equal(t, "*2", created.ID)
+
+	_, err = client.UpdateDNSRecord(ctx, mikrotik.DNSRecord{ID: created.ID, Address: "10.0.0.2"})
+	require.NoError(t, err)
+
+	found, err := client.FindDNSRecords(ctx, mikrotik.DNSRecord{Comment: "consul"})
+	require.NoError(t, err)
+	require.Equal(t, []mikrotik.DNSRecord{{ID: "*2", Name: "svc.local", Address: "10.0.0.2", Comment: "consul"}}, found)
+
+	require.NoError(t, client.DeleteDNSRecord(ctx, foreign))
+	require.Equal(t, found, srv.DNSRecords())
+}
+
+func TestServer_Errors(t *testing.T) {
+	srv := mikrotiktest.NewServer()
+	defer srv.Close()
+
+	client, err := mikrotik.New(srv.Config())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	_, err = client.CreateDNSRecord(ctx, mikrotik.DNSRecord{Address: "10.0.0.1"})
+	require.ErrorContains(t, err, `"error":400`)
+	require.ErrorContains(t, err, "either name or regexp must be set")
+
+	require.ErrorContains(t, client.DeleteDNSRecord(ctx, "*1"), `"error":404`)
+
+	cfg := srv.Config()
+	cfg.Password = "wrong"
+	client, err = mikrotik.New(cfg)
+	require.NoError(t, err)
+
+	_, err = client.FindDNSRecords(ctx, mikrotik.DNSRecord{})
+	require.ErrorContains(t, err, `"error":401`)
+}
+
+func TestServer_Proplist(t *testing.T) {
+	srv := mikrotiktest.NewServer()
+	defer srv.Close()
+
+	srv.Add(mikrotiktest.AddressList, mikrotik.AddressListEntry{List: "lan", Address: "10.0.0.1", Comment: "consul"})
+
+	client, err := mikrotik.New(srv.Config())
+	require.NoError(t, err)
+
+	entries, err := mikrotik.NewResource[mikrotik.AddressListEntry](client, mikrotiktest.AddressList).
+		WithProplist(".id", "list").
+		Find(context.Background(), mikrotik.AddressListEntry{List: "lan"})
+	require.NoError(t, err)
+	require.Equal(t, []mikrotik.AddressListEntry{{ID: "*1", List: "lan"}}, entries)
+}
@@ -0,0 +1,37 @@
+package mikrotik
+
+import "context"
+
+// NATRule represents a rule in MikroTik /ip/firewall/nat.
+// The ID field is populated by MikroTik and used for update/delete operations.
+type NATRule struct {
+	ID              string `json:".id,omitempty"               url:".id,omitempty"`
+	Chain           string `json:"chain,omitempty"             url:"chain,omitempty"`
+	Action          string `json:"action,omitempty"            url:"action,omitempty"`
+	Protocol        string `json:"protocol,omitempty"          url:"protocol,omitempty"`
+	DstPort         string `json:"dst-port,omitempty"          url:"dst-port,omitempty"`
+	InInterfaceList string `json:"in-interface-list,omitempty" url:"in-interface-list,omitempty"`
+	ToAddresses     string `json:"to-addresses,omitempty"      url:"to-addresses,omitempty"`
+	ToPorts         string `json:"to-ports,omitempty"          url:"to-ports,omitempty"`
+	Comment         string `json:"comment,omitempty"           url:"comment,omitempty"`
+}
+
+// CreateNATRule creates a new NAT rule and returns it with the assigned ID.
+func (c *Client) CreateNATRule(ctx context.Context, rule NATRule) (NATRule, error) {
+	return c.nat.Create(ctx, rule)
+}
+
+// UpdateNATRule updates the NAT rule identified by rule.ID.
+func (c *Client) UpdateNATRule(ctx context.Context, rule NATRule) (NATRule, error) {
+	return c.nat.Update(ctx, rule.ID, rule)
+}
+
+// FindNATRules returns all NAT rules matching the non-zero fields of filter.
+func (c *Client) FindNATRules(ctx context.Context, filter NATRule) ([]NATRule, error) {
+	return c.nat.Find(ctx, filter)
+}
+
+// DeleteNATRule deletes the NAT rule with the given MikroTik ID (e.g. "*1").
+func (c *Client) DeleteNATRule(ctx context.Context, id string) error {
+	return c.nat.Delete(ctx, id)
+}
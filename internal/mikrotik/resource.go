@@ -0,0 +1,120 @@
+package mikrotik
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/google/go-querystring/query"
+	"github.com/pkg/errors"
+)
+
+// Resource is a typed client for a RouterOS REST menu such as /ip/dns/static.
+// T is a struct describing a menu entry with json tags for the RouterOS property names
+// and url tags for the properties that can be used as Find filters.
+type Resource[T any] struct {
+	client   *Client
+	path     string
+	proplist string
+}
+
+// NewResource creates a Resource for the menu at path (e.g. "/ip/dns/static").
+// Find requests only the properties listed in the json tags of T.
+func NewResource[T any](client *Client, path string) *Resource[T] {
+	return &Resource[T]{
+		client:   client,
+		path:     path,
+		proplist: strings.Join(properties(reflect.TypeFor[T]()), ","),
+	}
+}
+
+// WithProplist returns a copy of the Resource that requests only the given properties on Find.
+// Without properties, Find returns every property of the entries.
+func (r *Resource[T]) WithProplist(names ...string) *Resource[T] {
+	clone := *r
+	clone.proplist = strings.Join(names, ",")
+	return &clone
+}
+
+// Create creates a new entry and returns it with the assigned ID.
+func (r *Resource[T]) Create(ctx context.Context, entry T) (T, error) {
+	return r.send(ctx, http.MethodPut, r.path, entry, http.StatusCreated)
+}
+
+// Update updates the entry with the given MikroTik ID.
+func (r *Resource[T]) Update(ctx context.Context, id string, entry T) (T, error) {
+	return r.send(ctx, http.MethodPatch, r.path+"/"+id, entry, http.StatusOK)
+}
+
+// Find returns all entries matching the non-zero fields of filter.
+func (r *Resource[T]) Find(ctx context.Context, filter T) ([]T, error) {
+	params, err := query.Values(filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "encode filter")
+	}
+	if r.proplist != "" {
+		params.Set(".proplist", r.proplist)
+	}
+	path := r.path
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	resp, data, err := r.client.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("expected 200, got %d: %s", resp.StatusCode, data)
+	}
+	var entries []T
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, errors.Wrap(err, "unmarshal response")
+	}
+	return entries, nil
+}
+
+// Delete deletes the entry with the given MikroTik ID (e.g. "*1").
+func (r *Resource[T]) Delete(ctx context.Context, id string) error {
+	resp, data, err := r.client.do(ctx, http.MethodDelete, r.path+"/"+id, nil)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		return errors.Errorf("expected 204, got %d: %s", resp.StatusCode, data)
+	}
+	return nil
+}
+
+func (r *Resource[T]) send(ctx context.Context, method, path string, entry T, status int) (T, error) {
+	var result T
+	resp, data, err := r.client.do(ctx, method, path, entry)
+	if err != nil {
+		return result, err
+	}
+	if resp.StatusCode != status {
+		return result, errors.Errorf("expected %d, got %d: %s", status, resp.StatusCode, data)
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, errors.Wrap(err, "unmarshal response")
+	}
+	return result, nil
+}
+
+// properties returns the RouterOS property names from the json tags of a struct type.
+func properties(typ reflect.Type) []string {
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var names []string
+	for i := range typ.NumField() {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,92 @@
+package mikrotik_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/jfk9w/consul-publish/internal/mikrotik"
+)
+
+type lease struct {
+	ID         string `json:".id,omitempty"          url:".id,omitempty"`
+	Address    string `json:"address,omitempty"     url:"address,omitempty"`
+	MACAddress string `json:"mac-address,omitempty" url:"mac-address,omitempty"`
+	Local      string `json:"-"                     url:"-"`
+}
+
+func TestResource_Find_Proplist(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockHTTP := NewMockHTTPClient(ctrl)
+
+	gomock.InOrder(
+		mockHTTP.EXPECT().
+			Do(gomock.Any()).
+			DoAndReturn(func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, "/rest/ip/dhcp-server/lease", req.URL.Path)
+				assert.Equal(t, ".id,address,mac-address", req.URL.Query().Get(".proplist"))
+				assert.Equal(t, "10.0.0.5", req.URL.Query().Get("address"))
+				return makeResponse(http.StatusOK, `[{".id":"*1","address":"10.0.0.5","mac-address":"AA:BB:CC:DD:EE:FF"}]`), nil
+			}),
+		mockHTTP.EXPECT().
+			Do(gomock.Any()).
+			DoAndReturn(func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, ".id", req.URL.Query().Get(".proplist"))
+				return makeResponse(http.StatusOK, `[{".id":"*1"}]`), nil
+			}),
+	)
+
+	leases := mikrotik.NewResource[lease](newMockClient(t, mockHTTP), "/ip/dhcp-server/lease")
+	got, err := leases.Find(context.Background(), lease{Address: "10.0.0.5"})
+	require.NoError(t, err)
+	assert.Equal(t, []lease{{ID: "*1", Address: "10.0.0.5", MACAddress: "AA:BB:CC:DD:EE:FF"}}, got)
+
+	got, err = leases.WithProplist(".id").Find(context.Background(), lease{})
+	require.NoError(t, err)
+	assert.Equal(t, []lease{{ID: "*1"}}, got)
+}
+
+func TestResource_CreateUpdateDelete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockHTTP := NewMockHTTPClient(ctrl)
+
+	gomock.InOrder(
+		mockHTTP.EXPECT().
+			Do(gomock.Any()).
+			DoAndReturn(func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, http.MethodPut, req.Method)
+				assert.Equal(t, "/rest/ip/dhcp-server/lease", req.URL.Path)
+				return makeResponse(http.StatusCreated, `{".id":"*7","address":"10.0.0.7"}`), nil
+			}),
+		mockHTTP.EXPECT().
+			Do(gomock.Any()).
+			DoAndReturn(func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, http.MethodPatch, req.Method)
+				assert.Equal(t, "/rest/ip/dhcp-server/lease/*7", req.URL.Path)
+
+				var body lease
+				require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+				assert.Equal(t, "10.0.0.8", body.Address)
+				return makeResponse(http.StatusOK, `{".id":"*7","address":"10.0.0.8"}`), nil
+			}),
+		mockHTTP.EXPECT().
+			Do(gomock.Any()).
+			Return(makeResponse(http.StatusBadRequest, `{"error":400,"message":"Bad Request"}`), nil),
+	)
+
+	leases := mikrotik.NewResource[lease](newMockClient(t, mockHTTP), "/ip/dhcp-server/lease")
+	created, err := leases.Create(context.Background(), lease{Address: "10.0.0.7"})
+	require.NoError(t, err)
+	assert.Equal(t, "*7", created.ID)
+
+	updated, err := leases.Update(context.Background(), created.ID, lease{Address: "10.0.0.8"})
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.8", updated.Address)
+
+	assert.ErrorContains(t, leases.Delete(context.Background(), created.ID), "expected 204, got 400")
+}
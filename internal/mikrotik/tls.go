@@ -0,0 +1,54 @@
+package mikrotik
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// tlsConfig builds the TLS settings for cfg. A pinned fingerprint replaces chain verification,
+// which allows using the self-signed certificate RouterOS generates by default.
+func tlsConfig(cfg Config) (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CA != "" {
+		data, err := os.ReadFile(cfg.CA)
+		if err != nil {
+			return nil, errors.Wrap(err, "read CA bundle")
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errors.Errorf("no certificates found in %s", cfg.CA)
+		}
+
+		config.RootCAs = pool
+	}
+
+	if cfg.Fingerprint != "" {
+		pin, err := hex.DecodeString(strings.ReplaceAll(cfg.Fingerprint, ":", ""))
+		if err != nil || len(pin) != sha256.Size {
+			return nil, errors.Errorf("invalid fingerprint %q: expected a hex-encoded SHA-256 digest", cfg.Fingerprint)
+		}
+
+		config.InsecureSkipVerify = true
+		config.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errors.New("no server certificate")
+			}
+
+			if sum := sha256.Sum256(rawCerts[0]); !bytes.Equal(sum[:], pin) {
+				return errors.Errorf("server certificate fingerprint %x does not match the pinned one", sum)
+			}
+
+			return nil
+		}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,75 @@
+package mikrotik_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jfk9w/consul-publish/internal/mikrotik"
+)
+
+func newTLSServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_HTTPS(t *testing.T) {
+	server := newTLSServer(t)
+	host := strings.TrimPrefix(server.URL, "https://")
+	sum := sha256.Sum256(server.Certificate().Raw)
+
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+
+	cases := map[string]struct {
+		cfg     mikrotik.Config
+		wantErr bool
+	}{
+		"untrusted":            {cfg: mikrotik.Config{}, wantErr: true},
+		"ca bundle":            {cfg: mikrotik.Config{CA: ca}},
+		"pinned fingerprint":   {cfg: mikrotik.Config{Fingerprint: hex.EncodeToString(sum[:])}},
+		"wrong fingerprint":    {cfg: mikrotik.Config{Fingerprint: strings.Repeat("00:", 31) + "00"}, wantErr: true},
+		"insecure skip verify": {cfg: mikrotik.Config{InsecureSkipVerify: true}},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tc.cfg.Host = host
+			tc.cfg.Scheme = "https"
+			c, err := mikrotik.New(tc.cfg)
+			require.NoError(t, err)
+
+			_, err = c.FindDNSRecords(context.Background(), mikrotik.DNSRecord{})
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNew_InvalidTLSConfig(t *testing.T) {
+	_, err := mikrotik.New(mikrotik.Config{Scheme: "ftp"})
+	assert.ErrorContains(t, err, `unsupported scheme "ftp"`)
+
+	_, err = mikrotik.New(mikrotik.Config{Fingerprint: "abc"})
+	assert.ErrorContains(t, err, "invalid fingerprint")
+
+	_, err = mikrotik.New(mikrotik.Config{CA: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.ErrorContains(t, err, "read CA bundle")
+}